@@ -1,79 +1,24 @@
 package main
 
 import (
-	"flag"
-	"fmt"
-	"log/slog"
+	"context"
 	"maps"
-	"os"
-	"reflect"
-	"runtime"
-	"slices"
 	"strings"
-	"time"
+
+	"main/aoc"
 )
 
 var width, height int
 
-func main() {
-	defaultInput := "input.txt"
-	defaultPart := ""
-
-	// defaultInput = "input-example-1.txt"
-	// defaultPart = "1"
-
-	var verboseDebug, outputFile bool
-	var inputPath, partFilter string
-	flag.StringVar(&inputPath, "input", defaultInput, "")
-	flag.StringVar(&partFilter, "part", defaultPart, "")
-	flag.BoolVar(&verboseDebug, "v", false, "verbose debug")
-	flag.BoolVar(&outputFile, "o", false, "write output file")
-	flag.Parse()
-
-	inputData, err := os.ReadFile(inputPath)
-	if err != nil {
-		slog.Error("could not read file", "path", inputPath, "err", err)
-		os.Exit(1)
-	}
-
-	input := strings.TrimSuffix(strings.ReplaceAll(string(inputData), "\r\n", "\n"), "\n")
-
-	for _, f := range []func(string, *Debugger) (any, error){Part1, Part2} {
-		funcName := strings.Split(runtime.FuncForPC(reflect.ValueOf(f).Pointer()).Name(), ".")[1]
-		if !strings.HasSuffix(funcName, partFilter) {
-			continue
-		}
-
-		debug := NewDebugBuilder(verboseDebug, fmt.Sprintf("debug-%s.txt", funcName), -1)
-		defer debug.Close()
-
-		start := time.Now()
-		result, err := f(input, debug)
-		duration := time.Since(start)
+func init() {
+	aoc.Register(aoc.Part{Name: "Part1", Year: 2024, Day: 10, Fn: Part1})
+	aoc.Register(aoc.Part{Name: "Part2", Year: 2024, Day: 10, Fn: Part2})
+}
 
-		if err != nil {
-			slog.Error("could not run part", "func", funcName, "err", err)
-			break
-		}
+//go:generate go run main/cmd/aoc gentest
 
-		if outputFile {
-			file, err := os.OpenFile(fmt.Sprintf("output-%s.txt", funcName), os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0777)
-			if err != nil {
-				slog.Error("could not create or append output file", "err", err)
-				os.Exit(1)
-			}
-			defer file.Close()
-
-			_, err = file.WriteString(fmt.Sprintf("%v", result))
-			if err != nil {
-				slog.Error("could not write result", "func", funcName, "result", result, "err", err)
-				break
-			}
-		}
-
-		debug.Close()
-		slog.Info("finished running part", "func", funcName, "duration", duration, "result", result)
-	}
+func main() {
+	aoc.Main()
 }
 
 var (
@@ -93,7 +38,7 @@ func (p Pos) Coords() (row int, col int) {
 	return int(p) / 100, int(p) % 100
 }
 
-func Part1(input string, debug *Debugger) (any, error) {
+func Part1(ctx context.Context, input string, debug *aoc.Debugger) (any, error) {
 	result := 0
 
 	var trailheads []Pos
@@ -125,11 +70,11 @@ func Part1(input string, debug *Debugger) (any, error) {
 	return result, nil
 }
 
-func followTrailPt1(debug *Debugger, field map[Pos]int, val int, pos Pos, path []Pos) Hash[Pos] {
+func followTrailPt1(debug *aoc.Debugger, field map[Pos]int, val int, pos Pos, path []Pos) Hash[Pos] {
 	ends := make(Hash[Pos])
 
 	path = append(path, pos)
-	debug.WriteFunc(debugPath(field, path))
+	debug.WriteGrid(debugPath(field, path))
 
 	if val == 9 {
 		ends.Add(pos)
@@ -151,7 +96,7 @@ func followTrailPt1(debug *Debugger, field map[Pos]int, val int, pos Pos, path [
 	return ends
 }
 
-func Part2(input string, debug *Debugger) (any, error) {
+func Part2(ctx context.Context, input string, debug *aoc.Debugger) (any, error) {
 	result := 0
 
 	var trailheads []Pos
@@ -183,13 +128,13 @@ func Part2(input string, debug *Debugger) (any, error) {
 	return result, nil
 }
 
-func followTrailPt2(debug *Debugger, field map[Pos]int, val int, pos Pos, path []Pos) int {
+func followTrailPt2(debug *aoc.Debugger, field map[Pos]int, val int, pos Pos, path []Pos) int {
 	count := 0
 
 	ends := make(Hash[Pos])
 
 	path = append(path, pos)
-	debug.WriteFunc(debugPath(field, path))
+	debug.WriteGrid(debugPath(field, path))
 
 	if val == 9 {
 		ends.Add(pos)
@@ -210,17 +155,16 @@ func followTrailPt2(debug *Debugger, field map[Pos]int, val int, pos Pos, path [
 	return count
 }
 
-func debugPath(field map[Pos]int, path []Pos) func() string {
-	return func() string {
-		meta := slices.Repeat([]rune(strings.Repeat(".", width)+"\n"), height)
-		data := slices.Repeat([]rune(strings.Repeat(".", width)+"\n"), height)
+func debugPath(field map[Pos]int, path []Pos) func() aoc.Grid {
+	return func() aoc.Grid {
+		cells := aoc.NewCells(width, height, '.')
+		heights := aoc.NewCells(width, height, ' ')
 		for _, pos := range path {
 			row, col := pos.Coords()
-			n := (row)*(width+1) + col
-			data[n] = '#'
-			meta[n] = rune(field[pos] + 48)
+			cells[row][col] = '#'
+			heights[row][col] = rune(field[pos] + 48)
 		}
 
-		return VisualizeStep + string(meta) + VisualizeData + string(data)
+		return aoc.Grid{Cells: cells, Layers: []aoc.Layer{{Name: "Height", Cells: heights}}}
 	}
 }
@@ -1,70 +1,33 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
-	"log/slog"
-	"os"
-	"reflect"
-	"runtime"
 	"strconv"
 	"strings"
-	"time"
-)
-
-var maxSteps, maxPt2Steps int
 
-func main() {
-	defaultInput := "input.txt"
-	defaultPart := ""
-	defaultMaxSteps := 25
-	defaultPt2MaxSteps := 75
-
-	// defaultInput = "input-example-2.txt"
-	// defaultPt2MaxSteps = 6
-	// defaultPart = "2"
-
-	var verboseDebug bool
-	var inputPath, partFilter string
-	flag.StringVar(&inputPath, "input", defaultInput, "")
-	flag.StringVar(&partFilter, "part", defaultPart, "")
-	flag.BoolVar(&verboseDebug, "v", false, "verbose debug")
-	flag.IntVar(&maxSteps, "steps", defaultMaxSteps, "max steps to iterate")
-	flag.IntVar(&maxPt2Steps, "steps2", defaultPt2MaxSteps, "max steps to iterate")
-	flag.Parse()
-
-	inputData, err := os.ReadFile(inputPath)
-	if err != nil {
-		slog.Error("could not read file", "path", inputPath, "err", err)
-		os.Exit(1)
-	}
+	"main/aoc"
+)
 
-	input := strings.TrimSuffix(strings.ReplaceAll(string(inputData), "\r\n", "\n"), "\n")
+// Defaults live here instead of main so generated example tests run with them too
+var maxSteps, maxPt2Steps = 25, 75
 
-	for _, f := range []func(string, *Debugger) (any, error){Part1, Part2} {
-		funcName := strings.Split(runtime.FuncForPC(reflect.ValueOf(f).Pointer()).Name(), ".")[1]
-		if !strings.HasSuffix(funcName, partFilter) {
-			continue
-		}
-
-		debug := NewDebugBuilder(verboseDebug, fmt.Sprintf("debug-%s.txt", funcName), -1)
-		defer debug.Close()
+func init() {
+	aoc.Register(aoc.Part{Name: "Part1", Year: 2024, Day: 11, Fn: Part1})
+	aoc.Register(aoc.Part{Name: "Part2", Year: 2024, Day: 11, Fn: Part2})
+}
 
-		start := time.Now()
-		result, err := f(input, debug)
-		duration := time.Since(start)
+//go:generate go run main/cmd/aoc gentest
 
-		if err != nil {
-			slog.Error("could not run part", "func", funcName, "err", err)
-			break
-		}
+func main() {
+	flag.IntVar(&maxSteps, "steps", maxSteps, "max steps to iterate")
+	flag.IntVar(&maxPt2Steps, "steps2", maxPt2Steps, "max steps to iterate")
 
-		debug.Close()
-		slog.Info("finished running part", "func", funcName, "duration", duration, "result", result)
-	}
+	aoc.Main()
 }
 
-func Part1(input string, debug *Debugger) (any, error) {
+func Part1(ctx context.Context, input string, debug *aoc.Debugger) (any, error) {
 	data := strings.Split(input, " ")
 	debug.WriteFunc(debugData(data))
 
@@ -113,7 +76,7 @@ func debugData[T map[string]int | []string](data T) func() string {
 	}
 }
 
-func Part2(input string, debug *Debugger) (any, error) {
+func Part2(ctx context.Context, input string, debug *aoc.Debugger) (any, error) {
 	result := 0
 
 	data := map[string]int{}
@@ -1,66 +1,21 @@
 package main
 
 import (
-	"flag"
-	"fmt"
-	"log/slog"
-	"os"
-	"reflect"
-	"runtime"
+	"context"
 	"strings"
-	"time"
-)
-
-var veryVerbose bool
-
-func main() {
-	defaultInput := "input.txt"
-	defaultPart := ""
-
-	// defaultInput = "input-example-3.txt"
-	// defaultPart = "1"
-
-	var verboseDebug bool
-	var inputPath, partFilter string
-	flag.StringVar(&inputPath, "input", defaultInput, "")
-	flag.StringVar(&partFilter, "part", defaultPart, "")
-	flag.BoolVar(&verboseDebug, "v", false, "verbose debug")
-	flag.BoolVar(&veryVerbose, "vv", false, "very verbose debug")
-	flag.Parse()
-
-	if veryVerbose {
-		verboseDebug = true
-	}
-
-	inputData, err := os.ReadFile(inputPath)
-	if err != nil {
-		slog.Error("could not read file", "path", inputPath, "err", err)
-		os.Exit(1)
-	}
-
-	input := strings.TrimSuffix(strings.ReplaceAll(string(inputData), "\r\n", "\n"), "\n")
-
-	for _, f := range []func(string, *Debugger) (any, error){Part1, Part2} {
-		funcName := strings.Split(runtime.FuncForPC(reflect.ValueOf(f).Pointer()).Name(), ".")[1]
-		if !strings.HasSuffix(funcName, partFilter) {
-			continue
-		}
 
-		debug := NewDebugBuilder(verboseDebug, fmt.Sprintf("debug-%s.txt", funcName), -1)
-		defer debug.Close()
+	"main/aoc"
+)
 
-		start := time.Now()
-		result, err := f(input, debug)
-		duration := time.Since(start)
+func init() {
+	aoc.Register(aoc.Part{Name: "Part1", Year: 2024, Day: 12, Fn: Part1})
+	aoc.Register(aoc.Part{Name: "Part2", Year: 2024, Day: 12, Fn: Part2})
+}
 
-		if err != nil {
-			slog.Error("could not run part", "func", funcName, "err", err)
-			break
-		}
+//go:generate go run main/cmd/aoc gentest
 
-		debug.Close()
-		slog.Info("finished running part", "func", funcName, "duration", duration, "result", result)
-	}
+func main() {
+	aoc.Main()
 }
 
 const (
@@ -91,7 +46,34 @@ type Crop struct {
 	Rune     rune
 }
 
-func Part1(input string, debug *Debugger) (any, error) {
+// cropGrid shows the crops that already have a region with a layer of their region and, with
+// sides, one of their number of sides
+func cropGrid(field [][]*Crop, sides bool) aoc.Grid {
+	height, width := len(field), len(field[0])
+	grid := aoc.Grid{Cells: aoc.NewCells(width, height, '.')}
+	regionLayer := aoc.Layer{Name: "Region", Cells: aoc.NewCells(width, height, ' ')}
+	sidesLayer := aoc.Layer{Name: "Sides", Cells: aoc.NewCells(width, height, ' ')}
+	for row := range field {
+		for col, crop := range field[row] {
+			if crop.Region == -1 {
+				continue
+			}
+
+			grid.Cells[row][col] = crop.Rune
+			// The last digit keeps the layer one byte per cell for the visualizer
+			regionLayer.Cells[row][col] = rune('0' + crop.Region%10)
+			sidesLayer.Cells[row][col] = rune(crop.Sides.Count() + 48)
+		}
+	}
+
+	grid.Layers = append(grid.Layers, regionLayer)
+	if sides {
+		grid.Layers = append(grid.Layers, sidesLayer)
+	}
+	return grid
+}
+
+func Part1(ctx context.Context, input string, debug *aoc.Debugger) (any, error) {
 	result := 0
 
 	lines := strings.Split(input, "\n")
@@ -112,27 +94,7 @@ func Part1(input string, debug *Debugger) (any, error) {
 	}
 
 	fireDebug := func() {
-		debug.WriteFunc(func() string {
-			s := VisualizeStep + "Orig" + strings.Repeat(" ", max(1, width-2)) + "Region" + strings.Repeat(" ", max(1, width-4)) + "Sides\n"
-			for _, row := range field {
-				ss := []rune(strings.Repeat(" ", width*3+4))
-
-				for col, crop := range row {
-					if crop.Region == -1 {
-						ss[col] = '.'
-						ss[col+width+2] = '.'
-						ss[col+width*2+4] = '.'
-					} else {
-						ss[col] = crop.Rune
-						ss[col+width+2] = rune(crop.Region + 48)
-						ss[col+width*2+4] = rune(crop.Sides.Count() + 48)
-					}
-				}
-
-				s += string(ss) + "\n"
-			}
-			return s
-		})
+		debug.WriteGrid(func() aoc.Grid { return cropGrid(field, true) })
 		debug.Flush()
 	}
 
@@ -146,21 +108,13 @@ func Part1(input string, debug *Debugger) (any, error) {
 					crop.Region = regionCounter
 				}
 
-				if veryVerbose {
-					fireDebug()
-				}
+				fireDebug()
 
 				regionCounter++
 			}
 		}
 	}
 
-	if !veryVerbose {
-		fireDebug()
-	}
-
-	debug.WriteString(VisualizeEnd)
-
 	for region := 0; region < len(regions); region++ {
 		crops := regions[region]
 
@@ -216,7 +170,7 @@ func pathCrops(field [][]*Crop, checked Hash[*Crop], row, col, width, height int
 	return crops
 }
 
-func Part2(input string, debug *Debugger) (any, error) {
+func Part2(ctx context.Context, input string, debug *aoc.Debugger) (any, error) {
 	result := 0
 
 	lines := strings.Split(input, "\n")
@@ -237,25 +191,7 @@ func Part2(input string, debug *Debugger) (any, error) {
 	}
 
 	fireDebug := func() {
-		debug.WriteFunc(func() string {
-			s := VisualizeStep + "Orig" + strings.Repeat(" ", max(1, width-2)) + "Region\n"
-			for _, row := range field {
-				ss := []rune(strings.Repeat(" ", width*2+2))
-
-				for col, crop := range row {
-					if crop.Region == -1 {
-						ss[col] = '.'
-						ss[col+width+2] = '.'
-					} else {
-						ss[col] = crop.Rune
-						ss[col+width+2] = rune(crop.Region + 48)
-					}
-				}
-
-				s += string(ss) + "\n"
-			}
-			return s
-		})
+		debug.WriteGrid(func() aoc.Grid { return cropGrid(field, false) })
 		debug.Flush()
 	}
 
@@ -269,21 +205,13 @@ func Part2(input string, debug *Debugger) (any, error) {
 					crop.Region = regionCounter
 				}
 
-				if veryVerbose {
-					fireDebug()
-				}
+				fireDebug()
 
 				regionCounter++
 			}
 		}
 	}
 
-	if !veryVerbose {
-		fireDebug()
-	}
-
-	debug.WriteString(VisualizeEnd)
-
 	for region := 0; region < len(regions); region++ {
 		crops := regions[region]
 
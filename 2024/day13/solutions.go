@@ -1,11 +1,13 @@
 package main
 
 import (
-	"flag"
+	"context"
 	"math"
 	"regexp"
 	"strconv"
 	"strings"
+
+	"main/aoc"
 )
 
 var (
@@ -14,20 +16,7 @@ var (
 	prizeRegex   = regexp.MustCompile(`Prize: X=(\d+), Y=(\d+)`)
 )
 
-func parseArgs() Args {
-	var args Args
-	flag.StringVar(&args.Input, "input", "input.txt", "")
-	flag.StringVar(&args.PartFilter, "part", "", "")
-	flag.BoolVar(&args.Verbose, "v", false, "verbose debug")
-	flag.Parse()
-
-	// args.Input = "input-example-1.txt"
-	// args.PartFilter = "1"
-
-	return args
-}
-
-func Part1(input string, debug *Debugger) (any, error) {
+func Part1(ctx context.Context, input string, debug *aoc.Debugger) (any, error) {
 	result := 0
 
 	var aX, aY, bX, bY, pX, pY int
@@ -86,7 +75,7 @@ func Part1(input string, debug *Debugger) (any, error) {
 	return result, nil
 }
 
-func Part2(input string, debug *Debugger) (any, error) {
+func Part2(ctx context.Context, input string, debug *aoc.Debugger) (any, error) {
 	result := 0
 	modifier := 10000000000000.0
 
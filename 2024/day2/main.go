@@ -2,62 +2,27 @@ package main
 
 import (
 	"fmt"
-	"log/slog"
-	"os"
-	"reflect"
-	"runtime"
 	"slices"
 	"strconv"
 	"strings"
-	"time"
 
-	_ "embed"
+	"main/aoc"
 )
 
-//go:embed input.txt
+// input is set to the input of the running part by aoc.InputVar
 var input string
 
-func main() {
-	for _, f := range []func() (any, string, error){Part1, Part2} {
-		funcName := strings.Split(runtime.FuncForPC(reflect.ValueOf(f).Pointer()).Name(), ".")[1]
-
-		start := time.Now()
-		result, debug, err := f()
-		duration := time.Since(start)
-
-		if err != nil {
-			slog.Error("could not run part", "func", funcName, "err", err)
-			break
-		}
-
-		outFile, err := os.OpenFile(fmt.Sprintf("output-%s.txt", funcName), os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0777)
-		if err != nil {
-			slog.Error("could not create or trunc output file", "err", err)
-			os.Exit(1)
-		}
-		defer outFile.Close()
-
-		_, err = outFile.WriteString(fmt.Sprintf("%v", result))
-		if err != nil {
-			slog.Error("could not write result", "func", funcName, "result", result, "err", err)
-			break
-		}
-
-		debugFile, err := os.OpenFile(fmt.Sprintf("debug-%s.txt", funcName), os.O_TRUNC|os.O_CREATE|os.O_WRONLY, 0777)
-		if err != nil {
-			slog.Error("could not create or append debug file", "err", err)
-			os.Exit(1)
-		}
-		defer debugFile.Close()
+func init() {
+	aoc.Register(aoc.Part{Name: "Part1", Year: 2024, Day: 2, Fn: aoc.InputVarDebugString(&input, Part1)})
+	aoc.Register(aoc.Part{Name: "Part2", Year: 2024, Day: 2, Fn: aoc.InputVarDebugString(&input, Part2)})
+}
 
-		_, err = debugFile.WriteString(debug)
-		if err != nil {
-			slog.Error("could not write debug", "func", funcName, "err", err)
-			break
-		}
+//go:generate go run main/cmd/aoc gentest
 
-		slog.Info("finished running part", "func", funcName, "duration", duration, "result", result)
-	}
+func main() {
+	// Older days always wrote their result
+	aoc.WriteOutput = true
+	aoc.Main()
 }
 
 func Part1() (any, string, error) {
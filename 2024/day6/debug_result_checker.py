@@ -1,3 +1,5 @@
+import json
+
 good = [
     "23,92",
     "32,92",
@@ -1483,11 +1485,12 @@ good = [
     "2,105",
 ]
 
+# Every frame is a JSON line after a record separator (RFC 7464) with the obstacle as its meta
 with open("debug-Part2.txt", "r") as file:
-    checkLine = False
-    for line in file.readlines():
-        line = line.strip()
-        if checkLine:
-            print(line, line in good)
+    for record in file.read().split("\x1e"):
+        line = record.split("\n", 1)[0].strip()
+        if not line.startswith("{"):
+            continue
 
-        checkLine = line == "==========STEP=========="
+        key = json.loads(line).get("meta", "")
+        print(key, key in good)
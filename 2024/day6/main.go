@@ -1,25 +1,17 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
-	"flag"
 	"fmt"
 	"log/slog"
-	"os"
-	"reflect"
-	"runtime"
 	"slices"
 	"strings"
-	"time"
 
-	_ "embed"
+	"main/aoc"
 )
 
-const VisualizeStep = "==========STEP==========\n"
-
 var (
-	veryVerboseDebug bool
-
 	DirectionChange = map[rune]rune{
 		'^': '>',
 		'>': 'V',
@@ -35,68 +27,19 @@ var (
 	pt1Result = -1
 )
 
-func main() {
-	var inputPath, partFilter string
-	var verboseDebug bool
-	flag.StringVar(&inputPath, "input", "input.txt", "")
-	flag.StringVar(&partFilter, "part", "", "")
-	flag.BoolVar(&verboseDebug, "v", false, "verbose debug")
-	flag.BoolVar(&veryVerboseDebug, "vv", false, "very verbose debug")
-	flag.Parse()
-
-	if veryVerboseDebug {
-		verboseDebug = true
-	}
-
-	// inputPath = "input-example-1.txt"
-	// partFilter = "2"
-	// verboseDebug = false
-
-	inputData, err := os.ReadFile(inputPath)
-	if err != nil {
-		slog.Error("could not read file", "path", inputPath, "err", err)
-		os.Exit(1)
-	}
-
-	input := strings.TrimSuffix(strings.ReplaceAll(string(inputData), "\r\n", "\n"), "\n")
-
-	for _, f := range []func(string, *Debugger) (any, error){Part1, Part2} {
-		funcName := strings.Split(runtime.FuncForPC(reflect.ValueOf(f).Pointer()).Name(), ".")[1]
-		if !strings.HasSuffix(funcName, partFilter) {
-			continue
-		}
-
-		debug := NewDebugBuilder(verboseDebug, fmt.Sprintf("debug-%s.txt", funcName), -1)
-		defer debug.Close()
-
-		start := time.Now()
-		result, err := f(input, debug)
-		duration := time.Since(start)
-
-		if err != nil {
-			slog.Error("could not run part", "func", funcName, "err", err)
-			break
-		}
+func init() {
+	aoc.Register(aoc.Part{Name: "Part1", Year: 2024, Day: 6, Fn: Part1})
+	aoc.Register(aoc.Part{Name: "Part2", Year: 2024, Day: 6, Fn: Part2})
+}
 
-		file, err := os.OpenFile(fmt.Sprintf("output-%s.txt", funcName), os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0777)
-		if err != nil {
-			slog.Error("could not create or append output file", "err", err)
-			os.Exit(1)
-		}
-		defer file.Close()
+//go:generate go run main/cmd/aoc gentest
 
-		_, err = file.WriteString(fmt.Sprintf("%v", result))
-		if err != nil {
-			slog.Error("could not write result", "func", funcName, "result", result, "err", err)
-			break
-		}
-
-		debug.Flush()
-		slog.Info("finished running part", "func", funcName, "duration", duration, "result", result)
-	}
+func main() {
+	aoc.WriteOutput = true
+	aoc.Main()
 }
 
-func Part1(input string, debug *Debugger) (any, error) {
+func Part1(ctx context.Context, input string, debug *aoc.Debugger) (any, error) {
 	result := 0
 
 	state := State{LoopCheck: map[int]int{}}
@@ -120,22 +63,26 @@ func Part1(input string, debug *Debugger) (any, error) {
 	}
 	state.Height++
 
-	b, _ := json.MarshalIndent(state, "", "    ")
-	debug.WriteString(string(b) + "\n")
+	debug.At("parse", aoc.Debug).WriteFunc(func() string {
+		b, _ := json.MarshalIndent(state, "", "    ")
+		return string(b) + "\n"
+	})
 
 	for !state.GuardOutOfBounds() {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
 		fmt.Printf("\rStep: %d", state.StepCount)
 		state = state.Step()
 
-		if (state.Width == 10 || (state.Width > 10 && state.StepCount%25 == 0)) || veryVerboseDebug {
-			debug.WriteFunc(func() string { return VisualizeStep + state.Debug() })
-		}
+		debug.At("step", aoc.Debug).WriteGrid(state.Grid)
 	}
 	fmt.Print("\r")
 	slog.Info("out of bounds", "func", "Part1", "step", state.StepCount, "X", state.Guard.Pos.X, "Y", state.Guard.Pos.Y, "W", state.Width, "H", state.Height)
 
 	lastMapState := state.Debug()
-	debug.WriteString(VisualizeStep + lastMapState)
+	debug.WriteGrid(state.Grid)
 
 	for _, r := range lastMapState {
 		if r == '|' || r == '-' || r == '+' || r == '@' {
@@ -147,7 +94,7 @@ func Part1(input string, debug *Debugger) (any, error) {
 	return result, nil
 }
 
-func Part2(input string, debug *Debugger) (any, error) {
+func Part2(ctx context.Context, input string, debug *aoc.Debugger) (any, error) {
 	slog.Error("this algorithm is invalid currently. It's 82 too high for my input, but the invalids don't make sense so I'm gonna move on...")
 
 	result := 0
@@ -179,6 +126,10 @@ func Part2(input string, debug *Debugger) (any, error) {
 
 	var loopedStates []State
 	for i := len(finalState.Paths) - 1; i >= 1; i-- {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
 		fmt.Printf("\rStep: %d", i)
 
 		// if finalState.Paths[i].Hit.Symbol != 0 {
@@ -193,11 +144,11 @@ func Part2(input string, debug *Debugger) (any, error) {
 
 		for !state.GuardOutOfBounds() {
 			state = state.Step()
-			// clearScreen()
+			// aoc.ClearScreen()
 			// fmt.Printf("~~%d\n%s", i, state.Debug())
 
 			if state.InLoop {
-				// clearScreen()
+				// aoc.ClearScreen()
 				// fmt.Printf("~~%d\n%s", i, state.Debug())
 
 				loopedStates = append(loopedStates, state)
@@ -213,7 +164,11 @@ func Part2(input string, debug *Debugger) (any, error) {
 		key := fmt.Sprintf("%d,%d", o.Y, o.X)
 		if _, ok := uniqueObstacles[key]; !ok {
 			result++
-			debug.WriteFunc(func() string { return VisualizeStep + key + "\n" + state.Debug() })
+			debug.WriteGrid(func() aoc.Grid {
+				grid := state.Grid()
+				grid.Meta = key
+				return grid
+			})
 		}
 		uniqueObstacles[key]++
 	}
@@ -303,60 +258,50 @@ func (s State) CheckHit() (Obstacle, Pos) {
 	return zeroObstacle, newPos
 }
 
-func (s State) Debug() string {
-	var builder strings.Builder
-	builder.Grow(s.Height * (s.Width + 1))
-	for y := 0; y < s.Height; y++ {
-		for x := 0; x < s.Width; x++ {
-			if s.Guard.X == x && s.Guard.Y == y {
-				builder.WriteString(AnsiColorRed)
-				builder.WriteRune(s.Guard.Dir)
-				builder.WriteString(AnsiColorReset)
-				continue
-			}
+// Grid is the map with the guard, the obstacles and the paths walked so far
+func (s State) Grid() aoc.Grid {
+	grid := aoc.Grid{Cells: aoc.NewCells(s.Width, s.Height, '.')}
+	for i, guard := range s.Paths {
+		if guard.X < 0 || guard.X >= s.Width || guard.Y < 0 || guard.Y >= s.Height {
+			continue
+		}
 
-			hasObstacle := false
-			for _, obstacle := range s.Obstacles {
-				if obstacle.X == x && obstacle.Y == y {
-					hasObstacle = true
-					if obstacle.Symbol == '#' {
-						builder.WriteString(AnsiColorMagenta)
-					} else {
-						builder.WriteString(AnsiColorBlue)
-					}
-					builder.WriteRune(obstacle.Symbol)
-					builder.WriteString(AnsiColorReset)
-					break
-				}
-			}
-			if hasObstacle {
-				continue
-			}
+		cell := &grid.Cells[guard.Y][guard.X]
+		if i == 0 || *cell == '@' {
+			*cell = '@'
+			continue
+		}
 
-			pathSymbol := ""
-			for i, guard := range s.Paths {
-				if guard.X == x && guard.Y == y {
-					if i == 0 {
-						pathSymbol = "@"
-						break
-					}
+		symbol := []rune(DirectionSymbol[guard.Dir])[0]
+		if *cell != '.' && *cell != symbol {
+			*cell = '+'
+			continue
+		}
+		*cell = symbol
+	}
 
-					symbol := DirectionSymbol[guard.Dir]
-					if pathSymbol != "" && pathSymbol != symbol {
-						pathSymbol = "+"
-						continue
-					}
+	for _, obstacle := range slices.Backward(s.Obstacles) {
+		grid.Cells[obstacle.Y][obstacle.X] = obstacle.Symbol
+		color := aoc.AnsiColorMagenta
+		if obstacle.Symbol != '#' {
+			color = aoc.AnsiColorBlue
+		}
+		grid.Highlights = append(grid.Highlights, aoc.Highlight{X: obstacle.X, Y: obstacle.Y, Color: color})
+	}
 
-					pathSymbol = symbol
-				}
-			}
-			if pathSymbol != "" {
-				builder.WriteString(pathSymbol)
-				continue
-			}
+	if s.Guard.X >= 0 && s.Guard.X < s.Width && s.Guard.Y >= 0 && s.Guard.Y < s.Height {
+		grid.Cells[s.Guard.Y][s.Guard.X] = s.Guard.Dir
+		grid.Highlights = append(grid.Highlights, aoc.Highlight{X: s.Guard.X, Y: s.Guard.Y, Color: aoc.AnsiColorRed})
+	}
 
-			builder.WriteString(".")
-		}
+	return grid
+}
+
+func (s State) Debug() string {
+	var builder strings.Builder
+	builder.Grow(s.Height * (s.Width + 1))
+	for _, row := range s.Grid().Cells {
+		builder.WriteString(string(row))
 		builder.WriteString("\n")
 	}
 	return builder.String()
@@ -405,7 +350,7 @@ func (s State) SpeculateObstacle() (State, bool) {
 	for {
 		s = s.Step()
 
-		// fmt.Println(VisualizeStep + s.Debug())
+		// fmt.Println(s.Debug())
 
 		// Might not work if sim goes farther
 		if pt1Result != -1 && s.StepCount > pt1Result*4 {
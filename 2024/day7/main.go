@@ -1,82 +1,36 @@
 package main
 
 import (
-	"flag"
+	"context"
 	"fmt"
-	"log/slog"
 	"math"
 	"math/big"
-	"os"
-	"reflect"
-	"runtime"
 	"strconv"
 	"strings"
-	"time"
 
-	_ "embed"
+	"main/aoc"
 )
 
-func main() {
-	var verboseDebug bool
-	var inputPath, partFilter string
-	flag.StringVar(&inputPath, "input", "input.txt", "")
-	flag.StringVar(&partFilter, "part", "", "")
-	flag.BoolVar(&verboseDebug, "v", false, "verbose debug")
-	flag.Parse()
-
-	// inputPath = "input-example-1.txt"
-	// partFilter = "2"
-	// verboseDebug = true
-
-	inputData, err := os.ReadFile(inputPath)
-	if err != nil {
-		slog.Error("could not read file", "path", inputPath, "err", err)
-		os.Exit(1)
-	}
-
-	input := strings.TrimSuffix(strings.ReplaceAll(string(inputData), "\r\n", "\n"), "\n")
-
-	for _, f := range []func(string, *Debugger) (any, error){Part1, Part2} {
-		funcName := strings.Split(runtime.FuncForPC(reflect.ValueOf(f).Pointer()).Name(), ".")[1]
-		if !strings.HasSuffix(funcName, partFilter) {
-			continue
-		}
-
-		debug := NewDebugBuilder(verboseDebug, fmt.Sprintf("debug-%s.txt", funcName), -1)
-		defer debug.Close()
-
-		start := time.Now()
-		result, err := f(input, debug)
-		duration := time.Since(start)
-
-		if err != nil {
-			slog.Error("could not run part", "func", funcName, "err", err)
-			break
-		}
-
-		file, err := os.OpenFile(fmt.Sprintf("output-%s.txt", funcName), os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0777)
-		if err != nil {
-			slog.Error("could not create or append output file", "err", err)
-			os.Exit(1)
-		}
-		defer file.Close()
-
-		_, err = file.WriteString(fmt.Sprintf("%v", result))
-		if err != nil {
-			slog.Error("could not write result", "func", funcName, "result", result, "err", err)
-			break
-		}
+func init() {
+	aoc.Register(aoc.Part{Name: "Part1", Year: 2024, Day: 7, Fn: Part1})
+	aoc.Register(aoc.Part{Name: "Part2", Year: 2024, Day: 7, Fn: Part2})
+}
 
-		debug.Close()
+//go:generate go run main/cmd/aoc gentest
 
-		slog.Info("finished running part", "func", funcName, "duration", duration, "result", result)
-	}
+func main() {
+	aoc.WriteOutput = true
+	aoc.Main()
 }
 
-func Part1(input string, debug *Debugger) (any, error) {
+func Part1(ctx context.Context, input string, debug *aoc.Debugger) (any, error) {
 	result := 0
 
 	for ln, line := range strings.Split(input, "\n") {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
 		parts := strings.Split(line, ":")
 		expected := parts[0]
 		var nums []int
@@ -115,10 +69,14 @@ func Part1(input string, debug *Debugger) (any, error) {
 	return result, nil
 }
 
-func Part2(input string, debug *Debugger) (any, error) {
+func Part2(ctx context.Context, input string, debug *aoc.Debugger) (any, error) {
 	result := 0
 
 	for ln, line := range strings.Split(input, "\n") {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
 		parts := strings.Split(line, ":")
 		expected := parts[0]
 		var nums []int
@@ -162,7 +120,7 @@ func Part2(input string, debug *Debugger) (any, error) {
 	return result, nil
 }
 
-func recurseApplyOperators(ops []rune, data []int, actual string, debug *Debugger) string {
+func recurseApplyOperators(ops []rune, data []int, actual string, debug *aoc.Debugger) string {
 	if len(ops) == 0 {
 		return actual
 	}
@@ -1,87 +1,27 @@
 package main
 
 import (
-	"flag"
+	"context"
 	"fmt"
-	"log/slog"
-	"os"
-	"reflect"
-	"runtime"
 	"strings"
-	"time"
 
-	_ "embed"
-)
-
-const (
-	VisualizeStep = "==========STEP==========\n"
-	VisualizeData = "==========DATA==========\n"
+	"main/aoc"
 )
 
 // 991 too low
 
-func main() {
-	defaultInput := "input.txt"
-	defaultPart := ""
-
-	// defaultInput = "input-example-5.txt"
-	// defaultPart = "2"
-
-	var verboseDebug, outputFile bool
-	var inputPath, partFilter string
-	flag.StringVar(&inputPath, "input", defaultInput, "")
-	flag.StringVar(&partFilter, "part", defaultPart, "")
-	flag.BoolVar(&verboseDebug, "v", false, "verbose debug")
-	flag.BoolVar(&outputFile, "o", false, "write output file")
-	flag.Parse()
-
-	inputData, err := os.ReadFile(inputPath)
-	if err != nil {
-		slog.Error("could not read file", "path", inputPath, "err", err)
-		os.Exit(1)
-	}
-
-	input := strings.TrimSuffix(strings.ReplaceAll(string(inputData), "\r\n", "\n"), "\n")
-
-	for _, f := range []func(string, *Debugger) (any, error){Part1, Part2} {
-		funcName := strings.Split(runtime.FuncForPC(reflect.ValueOf(f).Pointer()).Name(), ".")[1]
-		if !strings.HasSuffix(funcName, partFilter) {
-			continue
-		}
-
-		debug := NewDebugBuilder(verboseDebug, fmt.Sprintf("debug-%s.txt", funcName), -1)
-		defer debug.Close()
-
-		start := time.Now()
-		result, err := f(input, debug)
-		duration := time.Since(start)
-
-		if err != nil {
-			slog.Error("could not run part", "func", funcName, "err", err)
-			break
-		}
-
-		if outputFile {
-			file, err := os.OpenFile(fmt.Sprintf("output-%s.txt", funcName), os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0777)
-			if err != nil {
-				slog.Error("could not create or append output file", "err", err)
-				os.Exit(1)
-			}
-			defer file.Close()
+func init() {
+	aoc.Register(aoc.Part{Name: "Part1", Year: 2024, Day: 8, Fn: Part1})
+	aoc.Register(aoc.Part{Name: "Part2", Year: 2024, Day: 8, Fn: Part2})
+}
 
-			_, err = file.WriteString(fmt.Sprintf("%v", result))
-			if err != nil {
-				slog.Error("could not write result", "func", funcName, "result", result, "err", err)
-				break
-			}
-		}
+//go:generate go run main/cmd/aoc gentest
 
-		debug.Close()
-		slog.Info("finished running part", "func", funcName, "duration", duration, "result", result)
-	}
+func main() {
+	aoc.Main()
 }
 
-func Part1(input string, debug *Debugger) (any, error) {
+func Part1(ctx context.Context, input string, debug *aoc.Debugger) (any, error) {
 	result := 0
 
 	lines := strings.Split(input, "\n")
@@ -91,20 +31,22 @@ func Part1(input string, debug *Debugger) (any, error) {
 	antenna := map[rune][]Pos{}
 	antinodes := map[Pos]struct{}{}
 
+	// meta collects what happened during the step for its frame
+	var meta strings.Builder
 	addAntinode := func(pos Pos) {
 		if pos.Row >= 0 && pos.Row < height && pos.Col >= 0 && pos.Col < width {
 			if _, ok := antinodes[pos]; !ok {
 				antinodes[pos] = struct{}{}
 				result++
-				debug.WriteFormat("In bounds: %v\n", pos)
+				fmt.Fprintf(&meta, "In bounds: %v\n", pos)
 				return
 			}
 
-			debug.WriteFormat("Already in bounds: %v\n", pos)
+			fmt.Fprintf(&meta, "Already in bounds: %v\n", pos)
 			return
 		}
 
-		debug.WriteFormat("Out of bounds: %v\n", pos)
+		fmt.Fprintf(&meta, "Out of bounds: %v\n", pos)
 	}
 
 	for row := range lines {
@@ -113,7 +55,7 @@ func Part1(input string, debug *Debugger) (any, error) {
 				continue
 			}
 
-			debug.WriteString(VisualizeStep)
+			meta.Reset()
 
 			if locations, ok := antenna[r]; ok {
 				for _, pos := range locations {
@@ -130,15 +72,19 @@ func Part1(input string, debug *Debugger) (any, error) {
 			}
 			antenna[r] = append(antenna[r], Pos{Row: row, Col: col})
 
-			debug.WriteFormat("Result: %d\n%s", len(antinodes), VisualizeData)
-			debug.WriteFunc(func() string { return fieldToString(height, width, antinodes, antenna) })
+			debug.WriteGrid(func() aoc.Grid {
+				return aoc.Grid{
+					Meta:  fmt.Sprintf("%sResult: %d", meta.String(), len(antinodes)),
+					Cells: fieldCells(height, width, antinodes, antenna),
+				}
+			})
 		}
 	}
 
 	return result, nil
 }
 
-func Part2(input string, debug *Debugger) (any, error) {
+func Part2(ctx context.Context, input string, debug *aoc.Debugger) (any, error) {
 	result := 0
 
 	lines := strings.Split(input, "\n")
@@ -148,20 +94,22 @@ func Part2(input string, debug *Debugger) (any, error) {
 	antenna := map[rune][]Pos{}
 	antinodes := map[Pos]struct{}{}
 
+	// meta collects what happened during the step for its frame
+	var meta strings.Builder
 	addAntinode := func(pos Pos) bool {
 		if pos.Row >= 0 && pos.Row < height && pos.Col >= 0 && pos.Col < width {
 			if _, ok := antinodes[pos]; !ok {
 				antinodes[pos] = struct{}{}
 				result++
-				debug.WriteFormat("In bounds: %v\n", pos)
+				fmt.Fprintf(&meta, "In bounds: %v\n", pos)
 				return true
 			}
 
-			debug.WriteFormat("Already in bounds: %v\n", pos)
+			fmt.Fprintf(&meta, "Already in bounds: %v\n", pos)
 			return true
 		}
 
-		debug.WriteFormat("Out of bounds: %v\n", pos)
+		fmt.Fprintf(&meta, "Out of bounds: %v\n", pos)
 		return false
 	}
 
@@ -171,7 +119,7 @@ func Part2(input string, debug *Debugger) (any, error) {
 				continue
 			}
 
-			debug.WriteString(VisualizeStep)
+			meta.Reset()
 
 			if locations, ok := antenna[r]; ok {
 				for _, pos := range locations {
@@ -197,8 +145,12 @@ func Part2(input string, debug *Debugger) (any, error) {
 			}
 			antenna[r] = append(antenna[r], Pos{Row: row, Col: col})
 
-			debug.WriteFormat("Result: %d\n%s", len(antinodes), VisualizeData)
-			debug.WriteFunc(func() string { return fieldToString(height, width, antinodes, antenna) })
+			debug.WriteGrid(func() aoc.Grid {
+				return aoc.Grid{
+					Meta:  fmt.Sprintf("%sResult: %d", meta.String(), len(antinodes)),
+					Cells: fieldCells(height, width, antinodes, antenna),
+				}
+			})
 			debug.Flush()
 		}
 	}
@@ -210,12 +162,8 @@ type Pos struct {
 	Row, Col int
 }
 
-func fieldToString(height, width int, antinodes map[Pos]struct{}, antenna map[rune][]Pos) string {
-	var field [][]rune
-
-	for row := 0; row < height; row++ {
-		field = append(field, []rune(strings.Repeat(".", width)))
-	}
+func fieldCells(height, width int, antinodes map[Pos]struct{}, antenna map[rune][]Pos) [][]rune {
+	field := aoc.NewCells(width, height, '.')
 
 	for pos := range antinodes {
 		field[pos.Row][pos.Col] = '#'
@@ -227,10 +175,5 @@ func fieldToString(height, width int, antinodes map[Pos]struct{}, antenna map[ru
 		}
 	}
 
-	output := ""
-	for _, row := range field {
-		output += string(row) + "\n"
-	}
-
-	return output
+	return field
 }
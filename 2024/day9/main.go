@@ -1,78 +1,26 @@
 package main
 
 import (
-	"flag"
+	"context"
 	"fmt"
 	"log/slog"
-	"os"
-	"reflect"
-	"runtime"
-	"strings"
-	"time"
-)
-
-func main() {
-	defaultInput := "input.txt"
-	defaultPart := ""
-
-	// defaultInput = "input-example-1.txt"
-	// defaultPart = "2"
-
-	var verboseDebug, outputFile bool
-	var inputPath, partFilter string
-	flag.StringVar(&inputPath, "input", defaultInput, "")
-	flag.StringVar(&partFilter, "part", defaultPart, "")
-	flag.BoolVar(&verboseDebug, "v", false, "verbose debug")
-	flag.BoolVar(&outputFile, "o", false, "write output file")
-	flag.Parse()
-
-	inputData, err := os.ReadFile(inputPath)
-	if err != nil {
-		slog.Error("could not read file", "path", inputPath, "err", err)
-		os.Exit(1)
-	}
-
-	input := strings.TrimSuffix(strings.ReplaceAll(string(inputData), "\r\n", "\n"), "\n")
-
-	for _, f := range []func(string, *Debugger) (any, error){Part1, Part2} {
-		funcName := strings.Split(runtime.FuncForPC(reflect.ValueOf(f).Pointer()).Name(), ".")[1]
-		if !strings.HasSuffix(funcName, partFilter) {
-			continue
-		}
 
-		debug := NewDebugBuilder(verboseDebug, fmt.Sprintf("debug-%s.txt", funcName), -1)
-		defer debug.Close()
-
-		start := time.Now()
-		result, err := f(input, debug)
-		duration := time.Since(start)
-
-		if err != nil {
-			slog.Error("could not run part", "func", funcName, "err", err)
-			break
-		}
+	"main/aoc"
+	"main/frame"
+)
 
-		if outputFile {
-			file, err := os.OpenFile(fmt.Sprintf("output-%s.txt", funcName), os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0777)
-			if err != nil {
-				slog.Error("could not create or append output file", "err", err)
-				os.Exit(1)
-			}
-			defer file.Close()
+func init() {
+	aoc.Register(aoc.Part{Name: "Part1", Year: 2024, Day: 9, Fn: Part1})
+	aoc.Register(aoc.Part{Name: "Part2", Year: 2024, Day: 9, Fn: Part2})
+}
 
-			_, err = file.WriteString(fmt.Sprintf("%v", result))
-			if err != nil {
-				slog.Error("could not write result", "func", funcName, "result", result, "err", err)
-				break
-			}
-		}
+//go:generate go run main/cmd/aoc gentest
 
-		debug.Close()
-		slog.Info("finished running part", "func", funcName, "duration", duration, "result", result)
-	}
+func main() {
+	aoc.Main()
 }
 
-func Part1(input string, debug *Debugger) (any, error) {
+func Part1(ctx context.Context, input string, debug *aoc.Debugger) (any, error) {
 	result := 0
 
 	// Set ID to '0'
@@ -94,10 +42,7 @@ func Part1(input string, debug *Debugger) (any, error) {
 
 	slog.Info("disk created", "length", len(disk), "lastID", ID-48)
 
-	if ID <= '9' {
-		debug.WriteString(VisualizeStep + string(disk) + "\n")
-		debug.Flush()
-	}
+	debug.WriteFrameFunc(func() frame.Frame { return frame.Frame{Data: diskFrame(disk)} })
 
 	freeIdx := 0
 	numIdx := len(disk) - 1
@@ -119,11 +64,9 @@ func Part1(input string, debug *Debugger) (any, error) {
 		disk[freeIdx] = disk[numIdx]
 		disk[numIdx] = '.'
 
-		if ID <= '9' {
-			debug.WriteFormat("%s[%02d->%02d]\n%s%s\n", VisualizeStep, numIdx, freeIdx, VisualizeData, string(disk))
-		} else {
-			debug.WriteFormat("[%d->%d] %d\n", numIdx, freeIdx, disk[freeIdx]-48)
-		}
+		debug.WriteFrameFunc(func() frame.Frame {
+			return frame.Frame{Meta: fmt.Sprintf("[%02d->%02d]", numIdx, freeIdx), Data: diskFrame(disk)}
+		})
 	}
 
 	fmt.Print("\r")
@@ -138,7 +81,7 @@ func Part1(input string, debug *Debugger) (any, error) {
 	return result, nil
 }
 
-func Part2(input string, debug *Debugger) (any, error) {
+func Part2(ctx context.Context, input string, debug *aoc.Debugger) (any, error) {
 	result := 0
 
 	// Set ID to '0'
@@ -160,10 +103,7 @@ func Part2(input string, debug *Debugger) (any, error) {
 
 	slog.Info("disk created", "length", len(disk), "lastID", ID-48)
 
-	if ID <= '9' {
-		debug.WriteString(VisualizeStep + string(disk) + "\n")
-		debug.Flush()
-	}
+	debug.WriteFrameFunc(func() frame.Frame { return frame.Frame{Data: diskFrame(disk)} })
 
 	numIdx := len(disk)
 	for {
@@ -222,17 +162,16 @@ func Part2(input string, debug *Debugger) (any, error) {
 			disk[numIdx+i] = '.'
 		}
 
-		if ID <= '9' {
-			debug.WriteFormat("%s[%02d,%02d->%02d,%02d]\n%s%s\n", VisualizeStep, numIdx, numIdx+recordLength, freeIdx, freeIdx+freeLength, VisualizeData, string(disk))
-			debug.Flush()
-		} else {
-			debug.WriteFormat("[%d,%d->%d,%d] %d\n", numIdx, numIdx+recordLength, freeIdx, freeIdx+freeLength, disk[freeIdx]-48)
-		}
+		debug.WriteFrameFunc(func() frame.Frame {
+			return frame.Frame{
+				Meta: fmt.Sprintf("[%02d,%02d->%02d,%02d]", numIdx, numIdx+recordLength, freeIdx, freeIdx+freeLength),
+				Data: diskFrame(disk),
+			}
+		})
 	}
 
 	fmt.Print("\r")
 
-	debug.WriteString(VisualizeEnd)
 	for i, r := range disk {
 		if r == '.' {
 			continue
@@ -244,3 +183,16 @@ func Part2(input string, debug *Debugger) (any, error) {
 
 	return result, nil
 }
+
+// diskFrame draws every block as the last digit of its file ID, so the frame stays one byte per
+// block for the visualizer when there are more than ten files
+func diskFrame(disk []rune) string {
+	data := make([]byte, len(disk))
+	for i, r := range disk {
+		data[i] = '.'
+		if r != '.' {
+			data[i] = byte('0' + (r-48)%10)
+		}
+	}
+	return string(data)
+}
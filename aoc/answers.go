@@ -0,0 +1,115 @@
+package aoc
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+const AnswersFile = "answers.json"
+
+var numberRegex = regexp.MustCompile(`^-?\d+(\.\d+)?$`)
+
+type CheckStatus string
+
+const (
+	CheckPass CheckStatus = "PASS"
+	CheckFail CheckStatus = "FAIL"
+	CheckNew  CheckStatus = "NEW"
+)
+
+// Answers maps an input file to the expected answer of each part, e.g. {"input.txt": {"Part1": 42}}
+type Answers map[string]map[string]any
+
+type Check struct {
+	Status   CheckStatus
+	Expected string
+	Actual   string
+}
+
+func (c Check) String() string {
+	if c.Status == CheckFail {
+		return fmt.Sprintf("%s expected %s, got %s", c.Status, c.Expected, c.Actual)
+	}
+	return string(c.Status)
+}
+
+// LoadAnswers reads the answers file of a day directory. A missing file is not an error.
+func LoadAnswers(dir string) (Answers, error) {
+	answers := Answers{}
+
+	data, err := os.ReadFile(filepath.Join(dir, AnswersFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return answers, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read answers: %w", err)
+	}
+
+	// Numbers are kept as written so large answers don't turn into floats
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	err = decoder.Decode(&answers)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode answers: %w", err)
+	}
+
+	return answers, nil
+}
+
+func (a Answers) Save(dir string) error {
+	data, err := json.MarshalIndent(a, "", "    ")
+	if err != nil {
+		return fmt.Errorf("could not encode answers: %w", err)
+	}
+
+	err = os.WriteFile(filepath.Join(dir, AnswersFile), append(data, '\n'), 0644)
+	if err != nil {
+		return fmt.Errorf("could not write answers: %w", err)
+	}
+
+	return nil
+}
+
+// Check compares the result of a part against the expected answer. Results are compared by their
+// printed value since parts return any.
+func (a Answers) Check(input, part string, result any) Check {
+	check := Check{Status: CheckNew, Actual: fmt.Sprint(result)}
+
+	expected, ok := a[answerKey(input)][part]
+	if !ok {
+		return check
+	}
+
+	check.Expected = fmt.Sprint(expected)
+	check.Status = CheckPass
+	if check.Expected != check.Actual {
+		check.Status = CheckFail
+	}
+
+	return check
+}
+
+// Set records the answer for a part, replacing any existing one. Numeric answers are saved as JSON numbers.
+func (a Answers) Set(input, part string, result any) {
+	key := answerKey(input)
+	if a[key] == nil {
+		a[key] = map[string]any{}
+	}
+
+	value := fmt.Sprint(result)
+	if numberRegex.MatchString(value) {
+		a[key][part] = json.Number(value)
+	} else {
+		a[key][part] = value
+	}
+}
+
+func answerKey(input string) string {
+	return filepath.ToSlash(filepath.Clean(input))
+}
@@ -0,0 +1,49 @@
+// Package aoctest runs the examples of the registered parts of a day from its generated test, so
+// the testing package is only linked into tests and not into every day.
+package aoctest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+	"testing"
+
+	"main/aoc"
+)
+
+// TestExamples runs every registered part against its examples and the example inputs in
+// answers.json. Examples whose input file is missing are skipped.
+func TestExamples(t *testing.T) {
+	answers, err := aoc.LoadAnswers(".")
+	if err != nil {
+		t.Fatalf("could not load answers: %v", err)
+	}
+
+	for _, part := range aoc.Parts() {
+		for _, example := range part.AllExamples(answers) {
+			t.Run(example.Input+"/"+part.Name, func(t *testing.T) {
+				inputData, err := os.ReadFile(example.Input)
+				if errors.Is(err, fs.ErrNotExist) {
+					t.Skipf("example input %s does not exist", example.Input)
+				}
+				if err != nil {
+					t.Fatalf("could not read example input: %v", err)
+				}
+
+				input := strings.TrimSuffix(strings.ReplaceAll(string(inputData), "\r\n", "\n"), "\n")
+				result, err := part.Run(context.Background(), input, aoc.NewDebugger(aoc.Discard, -1))
+				if err != nil {
+					t.Fatalf("could not run part: %v", err)
+				}
+
+				want, got := fmt.Sprint(example.Want), fmt.Sprint(result)
+				if got != want {
+					t.Errorf("expected %s, got %s", want, got)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package aoc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"time"
+)
+
+const BaselineFile = "bench-baseline.json"
+
+type BenchResult struct {
+	Runs   int           `json:"runs"`
+	Min    time.Duration `json:"min_ns"`
+	Median time.Duration `json:"median_ns"`
+	P95    time.Duration `json:"p95_ns"`
+	// Allocs and Bytes are averages per run
+	Allocs uint64 `json:"allocs"`
+	Bytes  uint64 `json:"bytes"`
+}
+
+// Bench runs f once to warm up and then the given number of times, measuring the wall time and
+// allocations of every run. The first error of f stops the benchmark and is returned.
+func Bench(runs int, f func() error) (BenchResult, error) {
+	err := f()
+	if err != nil {
+		return BenchResult{}, err
+	}
+
+	durations := make([]time.Duration, runs)
+	var allocs, bytes uint64
+	var before, after runtime.MemStats
+	for i := range runs {
+		runtime.GC()
+		runtime.ReadMemStats(&before)
+
+		start := time.Now()
+		err := f()
+		durations[i] = time.Since(start)
+		if err != nil {
+			return BenchResult{}, err
+		}
+
+		runtime.ReadMemStats(&after)
+		allocs += after.Mallocs - before.Mallocs
+		bytes += after.TotalAlloc - before.TotalAlloc
+	}
+
+	slices.Sort(durations)
+	return BenchResult{
+		Runs:   runs,
+		Min:    durations[0],
+		Median: durations[len(durations)/2],
+		P95:    durations[int(math.Ceil(float64(len(durations))*0.95))-1],
+		Allocs: allocs / uint64(runs),
+		Bytes:  bytes / uint64(runs),
+	}, nil
+}
+
+func (b BenchResult) LogAttrs() []any {
+	return []any{"runs", b.Runs, "min", b.Min, "median", b.Median, "p95", b.P95, "allocs", b.Allocs, "bytes", b.Bytes}
+}
+
+type BenchComparison struct {
+	Baseline BenchResult
+	Current  BenchResult
+}
+
+func (b BenchResult) Compare(baseline BenchResult) BenchComparison {
+	return BenchComparison{Baseline: baseline, Current: b}
+}
+
+// Speedup is how many times faster the current median is than the baseline median.
+func (c BenchComparison) Speedup() float64 {
+	if c.Current.Median == 0 {
+		return math.Inf(1)
+	}
+	return float64(c.Baseline.Median) / float64(c.Current.Median)
+}
+
+func (c BenchComparison) LogAttrs() []any {
+	speedup := c.Speedup()
+
+	status := "same"
+	if speedup > 1.05 {
+		status = "faster"
+	} else if speedup < 0.95 {
+		status = "slower"
+	}
+
+	return []any{
+		"baseline", c.Baseline.Median,
+		"median", c.Current.Median,
+		"speedup", fmt.Sprintf("%.2fx", speedup),
+		"allocs", fmt.Sprintf("%+d", int64(c.Current.Allocs)-int64(c.Baseline.Allocs)),
+		"bytes", fmt.Sprintf("%+d", int64(c.Current.Bytes)-int64(c.Baseline.Bytes)),
+		"status", status,
+	}
+}
+
+// Baseline maps a part and its input, see BaselineKey, to its saved benchmark result
+type Baseline map[string]BenchResult
+
+// BaselineKey is the key of a part benchmarked on an input, e.g. "Part1 input.txt", so every input
+// has a baseline of its own
+func BaselineKey(part, inputPath string) string {
+	return part + " " + filepath.ToSlash(inputPath)
+}
+
+// LoadBaseline reads a baseline file. A missing file is an empty baseline.
+func LoadBaseline(path string) (Baseline, error) {
+	baseline := Baseline{}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return baseline, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read baseline: %w", err)
+	}
+
+	err = json.Unmarshal(data, &baseline)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode baseline: %w", err)
+	}
+
+	return baseline, nil
+}
+
+func (b Baseline) Save(path string) error {
+	data, err := json.MarshalIndent(b, "", "    ")
+	if err != nil {
+		return fmt.Errorf("could not encode baseline: %w", err)
+	}
+
+	err = os.WriteFile(path, append(data, '\n'), 0644)
+	if err != nil {
+		return fmt.Errorf("could not write baseline: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,90 @@
+package aoc
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Level is how detailed debug output is, a category enabled at a level also gets the levels below
+type Level int
+
+const (
+	Debug Level = iota
+	Trace
+)
+
+var levelNames = map[string]Level{
+	"debug": Debug,
+	"trace": Trace,
+}
+
+// AllCategories enables every category in a DebugFilter, -v is the same as -debug=*
+const AllCategories = "*"
+
+// DebugFilter is the highest enabled level of every debug category
+type DebugFilter map[string]Level
+
+// ParseDebugFilter parses a comma separated list of categories with an optional level like
+// "parse,step:trace". A category without a level is enabled at Debug.
+func ParseDebugFilter(s string) (DebugFilter, error) {
+	filter := DebugFilter{}
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		category, levelName, found := strings.Cut(entry, ":")
+		level := Debug
+		if found {
+			var ok bool
+			level, ok = levelNames[strings.ToLower(levelName)]
+			if !ok {
+				return nil, fmt.Errorf("unknown debug level %#v", levelName)
+			}
+		}
+		filter[category] = max(filter[category], level)
+	}
+	return filter, nil
+}
+
+// Enabled reports whether output of the category at the level is written. A category that isn't
+// listed falls back to AllCategories.
+func (f DebugFilter) Enabled(category string, level Level) bool {
+	enabled, ok := f[category]
+	if !ok {
+		enabled, ok = f[AllCategories]
+	}
+	return ok && level <= enabled
+}
+
+// offDebugger is handed out by At for a disabled category, everything written to it is dropped
+var offDebugger = &Debugger{sink: Discard}
+
+// SetFilter changes which categories At enables. Without a filter every category is enabled.
+func (d *Debugger) SetFilter(filter DebugFilter) {
+	for _, t := range d.tee {
+		t.SetFilter(filter)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.filter = filter
+	d.views = nil
+}
+
+// At returns the debugger for output of a category at a level, e.g.
+// debug.At("parse", aoc.Trace).WriteFormat(...). Output of a disabled category is dropped.
+// Writing to the debugger itself is the same as a category that is enabled whenever it is active.
+func (d *Debugger) At(category string, level Level) *Debugger {
+	if d.tee != nil {
+		return d.teeAt(category, level)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.filter != nil && !d.filter.Enabled(category, level) {
+		return offDebugger
+	}
+	return d
+}
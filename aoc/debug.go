@@ -0,0 +1,418 @@
+package aoc
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"sync"
+
+	"main/frame"
+)
+
+const (
+	AnsiColorReset = "\033[m"
+
+	AnsiColorDarkBlack   = "\033[0;30m"
+	AnsiColorDarkRed     = "\033[0;31m"
+	AnsiColorDarkGreen   = "\033[0;32m"
+	AnsiColorDarkYellow  = "\033[0;33m"
+	AnsiColorDarkBlue    = "\033[0;34m"
+	AnsiColorDarkMagenta = "\033[0;35m"
+	AnsiColorDarkCyan    = "\033[0;36m"
+	AnsiColorDarkWhite   = "\033[0;37m"
+
+	AnsiColorBlack   = "\033[0;90m"
+	AnsiColorRed     = "\033[0;91m"
+	AnsiColorGreen   = "\033[0;92m"
+	AnsiColorYellow  = "\033[0;93m"
+	AnsiColorBlue    = "\033[0;94m"
+	AnsiColorMagenta = "\033[0;95m"
+	AnsiColorCyan    = "\033[0;96m"
+	AnsiColorWhite   = "\033[0;97m"
+)
+
+func stripAnsi(s string) string {
+	s, _ = frame.ParseAnsi(s)
+	return s
+}
+
+func ClearScreen() {
+	cmd := exec.Command("cmd", "/c", "cls")
+	cmd.Stdout = os.Stdout
+	err := cmd.Run()
+	if err != nil {
+		slog.Error("could not clear screen", "err", err)
+	}
+}
+
+// Backpressure is what a Debugger does when its sink falls behind and the write queue is full
+type Backpressure int
+
+const (
+	// BackpressureBlock makes the writing part wait for the sink
+	BackpressureBlock Backpressure = iota
+	// BackpressureDrop drops the output that doesn't fit in the queue and counts the dropped frames
+	BackpressureDrop
+)
+
+// debugQueueBytes is how many bytes of flushes can wait for the sink. A larger flush waits for
+// the queue to be empty.
+const debugQueueBytes = 64 * 1024 * 1024
+
+// debugFlushMB is how large the buffer grows before it is handed to the sink by default, small
+// enough that several flushes fit in the queue
+const debugFlushMB = 4
+
+// chunk is one flush of the buffer on its way to the sink
+type chunk struct {
+	data   []byte
+	frames int
+}
+
+// Debugger buffers the debug output of a part and hands it to a goroutine writing to its sink
+// whenever the buffer grows past the flush size and on Close, so the part doesn't wait for the
+// sink. It is safe to use from several goroutines. The first error stops all debug output and is
+// returned by Flush and Close, the part itself keeps running.
+type Debugger struct {
+	mu           sync.Mutex
+	builder      bytes.Buffer
+	active       bool
+	sink         Sink
+	writeAtLen   int
+	writtenBytes int
+	closed       bool
+	// detached drops every write of a part that was left running after it was cancelled
+	detached bool
+	steps    int
+	sampler  sampler
+	filter   DebugFilter
+
+	// queue has the chunks waiting for the sink, it has its own lock so the writing goroutine
+	// doesn't wait for the part
+	queueMu      sync.Mutex
+	queueCond    *sync.Cond
+	queue        []chunk
+	queuedBytes  int
+	queueLimit   int
+	queueClosed  bool
+	done         chan struct{}
+	backpressure Backpressure
+	// bufferedFrames are the frames in the builder, dropped counts the frames of dropped chunks
+	bufferedFrames int
+	dropped        int
+
+	// err is the first error, it has its own lock since the writing goroutine sets it while a
+	// blocked write holds mu
+	errMu sync.Mutex
+	err   error
+
+	// tee are the debuggers a tee writes to instead of a sink of its own, views are the tees At
+	// handed out for the categories only some of them enable
+	tee   []*Debugger
+	views map[teeView]*Debugger
+}
+
+// NewDebugger writes to the sink, flushing every writeAtMB, or 4MB when negative. A live sink
+// like a socket gets every write right away. A Debugger writing to Discard is inactive and skips
+// the WriteFunc callbacks.
+func NewDebugger(sink Sink, writeAtMB int) *Debugger {
+	if writeAtMB < 0 {
+		writeAtMB = debugFlushMB
+	}
+	if isLive(sink) {
+		writeAtMB = 0
+	}
+
+	d := &Debugger{
+		active:     sink != Discard,
+		sink:       sink,
+		writeAtLen: writeAtMB * 1024 * 1024,
+		queueLimit: debugQueueBytes,
+	}
+	if d.active {
+		d.queueCond = sync.NewCond(&d.queueMu)
+		d.done = make(chan struct{})
+		go d.run()
+	}
+	return d
+}
+
+// SetBackpressure changes what happens when the sink falls behind, the default is to block
+func (d *Debugger) SetBackpressure(backpressure Backpressure) {
+	for _, t := range d.tee {
+		t.SetBackpressure(backpressure)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.backpressure = backpressure
+}
+
+// run writes the queued chunks to the sink until the queue is closed and empty. Everything after
+// the first error of the sink is discarded. A chunk counts against the queue until it is written.
+func (d *Debugger) run() {
+	defer close(d.done)
+
+	d.queueMu.Lock()
+	defer d.queueMu.Unlock()
+	for {
+		for len(d.queue) == 0 && !d.queueClosed {
+			d.queueCond.Wait()
+		}
+		if len(d.queue) == 0 {
+			return
+		}
+
+		c := d.queue[0]
+		d.queue = d.queue[1:]
+		d.queueMu.Unlock()
+
+		if d.Err() == nil {
+			_, err := d.sink.Write(c.data)
+			if err != nil {
+				d.setErr(fmt.Errorf("could not write to sink: %w", err))
+			}
+		}
+
+		d.queueMu.Lock()
+		d.queuedBytes -= len(c.data)
+		d.queueCond.Broadcast()
+	}
+}
+
+// Err returns the first error of the Debugger, nothing is written after it
+func (d *Debugger) Err() error {
+	if d.tee != nil {
+		return d.teeErr()
+	}
+
+	d.errMu.Lock()
+	defer d.errMu.Unlock()
+	return d.err
+}
+
+func (d *Debugger) setErr(err error) {
+	d.errMu.Lock()
+	defer d.errMu.Unlock()
+	if d.err == nil {
+		d.err = err
+	}
+}
+
+// stopped reports whether nothing is written anymore, it must be called with the lock held
+func (d *Debugger) stopped() bool {
+	return d.closed || d.detached || d.full() || d.Err() != nil
+}
+
+// detach makes the Debugger inert for the part writing to it, everything it writes from now on is
+// dropped. It is used for a part that ignored its cancellation and is left running, so the part
+// can't write into a Debugger that is closed or into the output of the next part.
+func (d *Debugger) detach() {
+	for _, t := range d.tee {
+		t.detach()
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.detached = true
+}
+
+func (d *Debugger) WriteString(s string) {
+	d.WriteFunc(func() string { return s })
+}
+
+func (d *Debugger) WriteFormat(format string, a ...any) {
+	d.WriteFunc(func() string { return fmt.Sprintf(format, a...) })
+}
+
+func (d *Debugger) WriteFunc(f func() string) {
+	if d.tee != nil {
+		d.teeWriteFunc(f)
+		return
+	}
+	if !d.active {
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.stopped() {
+		return
+	}
+
+	d.builder.WriteString(f())
+	d.writeIfOverTooLarge()
+}
+
+// WriteFrame writes the next step for the visualizer, numbering it and turning the colors of the
+// data into styles. A trailing newline of the data is dropped. The sampling can drop the frame or
+// hold it for Close.
+func (d *Debugger) WriteFrame(f frame.Frame) {
+	d.WriteFrameFunc(func() frame.Frame { return f })
+}
+
+// WriteFrameFunc is WriteFrame for a frame that is only built when the sampling keeps it
+func (d *Debugger) WriteFrameFunc(f func() frame.Frame) {
+	if d.tee != nil {
+		f = sync.OnceValue(f)
+		for _, t := range d.tee {
+			t.WriteFrameFunc(f)
+		}
+		return
+	}
+	if !d.active {
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.stopped() {
+		return
+	}
+
+	d.steps++
+	if d.sampler.skip(d.steps) {
+		return
+	}
+
+	fr := f()
+	fr.Version = frame.Version
+	fr.Step = d.steps
+	fr.Meta = stripAnsi(strings.TrimSuffix(fr.Meta, "\n"))
+	data, styles := frame.ParseAnsi(strings.TrimSuffix(fr.Data, "\n"))
+	fr.Data = data
+	// The styles of a frame built once for a tee are shared with the other debuggers
+	fr.Styles = append(slices.Clip(fr.Styles), styles...)
+	if d.sampler.hold(fr) {
+		return
+	}
+
+	d.writeFrame(fr)
+}
+
+func (d *Debugger) writeFrame(f frame.Frame) {
+	err := frame.Write(&d.builder, f)
+	if err != nil {
+		d.setErr(err)
+		return
+	}
+	d.bufferedFrames++
+	d.writeIfOverTooLarge()
+}
+
+func (d *Debugger) writeIfOverTooLarge() {
+	if d.builder.Len() > d.writeAtLen {
+		d.write()
+	}
+}
+
+// Close writes the frames held by the sampling, waits until everything queued is written and
+// closes the sink. It returns the first error of the Debugger, closing again returns it again.
+// Dropped then has the number of dropped frames.
+func (d *Debugger) Close() error {
+	if d.tee != nil {
+		return d.teeClose()
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.closed || !d.active {
+		return d.Err()
+	}
+
+	// The held frames were written before a detach
+	for _, f := range d.sampler.held() {
+		if d.full() || d.Err() != nil {
+			break
+		}
+		d.writeFrame(f)
+	}
+
+	d.write()
+	d.closed = true
+	d.queueMu.Lock()
+	d.queueClosed = true
+	d.queueCond.Broadcast()
+	d.queueMu.Unlock()
+	<-d.done
+
+	err := d.sink.Close()
+	if err != nil {
+		d.setErr(fmt.Errorf("could not close debug sink: %w", err))
+	}
+	return d.Err()
+}
+
+// Dropped is the number of frames dropped by BackpressureDrop
+func (d *Debugger) Dropped() int {
+	if d.tee != nil {
+		return d.teeSum((*Debugger).Dropped)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.dropped
+}
+
+func (d *Debugger) Len() int {
+	if d.tee != nil {
+		return d.teeSum((*Debugger).Len)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.len()
+}
+
+func (d *Debugger) len() int {
+	return d.builder.Len() + d.writtenBytes
+}
+
+// Flush hands the buffer to the sink without waiting for it to be written. It returns the first
+// error so far, an error of this write may only be returned by a later Flush or Close.
+func (d *Debugger) Flush() error {
+	if d.tee != nil {
+		return d.teeFlush()
+	}
+	if !d.active {
+		return d.Err()
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.write()
+	return d.Err()
+}
+
+// write queues the buffer for the sink, it must be called with the lock held
+func (d *Debugger) write() {
+	if d.builder.Len() == 0 || d.closed || d.Err() != nil {
+		return
+	}
+
+	c := chunk{data: bytes.Clone(d.builder.Bytes()), frames: d.bufferedFrames}
+	d.builder.Reset()
+	d.bufferedFrames = 0
+
+	d.queueMu.Lock()
+	defer d.queueMu.Unlock()
+	fits := func() bool {
+		return d.queuedBytes == 0 || d.queuedBytes+len(c.data) <= d.queueLimit
+	}
+	if d.backpressure == BackpressureDrop && !fits() {
+		d.dropped += c.frames
+		return
+	}
+	for !fits() {
+		d.queueCond.Wait()
+	}
+
+	d.queue = append(d.queue, c)
+	d.queuedBytes += len(c.data)
+	d.queueCond.Broadcast()
+	d.writtenBytes += len(c.data)
+}
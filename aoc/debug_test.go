@@ -0,0 +1,292 @@
+package aoc
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"main/frame"
+)
+
+// gatedSink blocks every write until the gate is opened, like a sink that falls behind
+type gatedSink struct {
+	MemorySink
+	gate chan struct{}
+	mu   sync.Mutex
+}
+
+func newGatedSink() *gatedSink {
+	return &gatedSink{gate: make(chan struct{})}
+}
+
+func (s *gatedSink) Write(p []byte) (int, error) {
+	<-s.gate
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.MemorySink.Write(p)
+}
+
+func (s *gatedSink) String() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.MemorySink.String()
+}
+
+// failingSink fails every write
+type failingSink struct {
+	writes int
+}
+
+func (s *failingSink) Write(p []byte) (int, error) {
+	s.writes++
+	return 0, errors.New("disk full")
+}
+
+func (s *failingSink) Close() error { return nil }
+
+// frameSteps returns the step numbers of the frames in the debug output
+func frameSteps(output string) []int {
+	var parser frame.Parser
+	frames := append(parser.Feed([]byte(output)), parser.Close()...)
+
+	steps := []int{}
+	for _, f := range frames {
+		steps = append(steps, f.Step)
+	}
+	return steps
+}
+
+func TestDebuggerWrites(t *testing.T) {
+	tests := []struct {
+		name  string
+		write func(d *Debugger)
+		want  string
+	}{
+		{name: "string", write: func(d *Debugger) { d.WriteString("a\n") }, want: "a\n"},
+		{name: "format", write: func(d *Debugger) { d.WriteFormat("%d,%d\n", 1, 2) }, want: "1,2\n"},
+		{name: "func", write: func(d *Debugger) { d.WriteFunc(func() string { return "b" }) }, want: "b"},
+		{name: "frame", write: func(d *Debugger) {
+			d.WriteFrame(frame.Frame{Meta: "m\n", Data: "#.\n"})
+		}, want: "\x1e{\"v\":1,\"step\":1,\"meta\":\"m\",\"data\":\"#.\"}\n"},
+		{name: "colors", write: func(d *Debugger) {
+			d.WriteFrame(frame.Frame{Data: "." + AnsiColorRed + "#" + AnsiColorReset})
+		}, want: "\x1e{\"v\":1,\"step\":1,\"data\":\".#\",\"styles\":[{\"offset\":1,\"length\":1,\"color\":\"9\"}]}\n"},
+		{name: "flush", write: func(d *Debugger) {
+			d.WriteString("c")
+			d.Flush()
+			d.WriteString("d")
+		}, want: "cd"},
+	}
+	for _, test := range tests {
+		for _, writeAtMB := range []int{-1, 0} {
+			t.Run(test.name, func(t *testing.T) {
+				sink := &MemorySink{}
+				d := NewDebugger(sink, writeAtMB)
+				test.write(d)
+
+				err := d.Close()
+				if err != nil {
+					t.Fatalf("could not close debugger: %v", err)
+				}
+				if got := sink.String(); got != test.want {
+					t.Errorf("expected %q, got %q", test.want, got)
+				}
+				if d.Len() != len(test.want) {
+					t.Errorf("expected a length of %d, got %d", len(test.want), d.Len())
+				}
+			})
+		}
+	}
+}
+
+func TestDebuggerInactive(t *testing.T) {
+	d := NewDebugger(Discard, -1)
+	d.WriteFunc(func() string {
+		t.Error("expected an inactive debugger to skip the callback")
+		return ""
+	})
+	d.WriteFrameFunc(func() frame.Frame {
+		t.Error("expected an inactive debugger to skip the callback")
+		return frame.Frame{}
+	})
+	if err := d.Close(); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+}
+
+func TestDebuggerSampling(t *testing.T) {
+	tests := []struct {
+		name     string
+		sampling Sampling
+		frames   int
+		want     []int
+	}{
+		{name: "everything", frames: 5, want: []int{1, 2, 3, 4, 5}},
+		{name: "every", sampling: Sampling{Every: 2}, frames: 6, want: []int{1, 3, 5}},
+		{name: "keep last", sampling: Sampling{KeepLast: 2}, frames: 6, want: []int{1, 5, 6}},
+		{name: "keep more than written", sampling: Sampling{KeepLast: 10}, frames: 3, want: []int{1, 2, 3}},
+		{name: "every and keep last", sampling: Sampling{Every: 2, KeepLast: 2}, frames: 9, want: []int{1, 7, 9}},
+		{name: "max bytes", sampling: Sampling{MaxBytes: 1}, frames: 3, want: []int{1}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sink := &MemorySink{}
+			d := NewDebugger(sink, -1)
+			d.SetSampling(test.sampling)
+			for range test.frames {
+				d.WriteFrame(frame.Frame{Data: "#"})
+			}
+
+			err := d.Close()
+			if err != nil {
+				t.Fatalf("could not close debugger: %v", err)
+			}
+			if got := frameSteps(sink.String()); !slices.Equal(got, test.want) {
+				t.Errorf("expected steps %v, got %v", test.want, got)
+			}
+		})
+	}
+}
+
+func TestDebuggerBackpressure(t *testing.T) {
+	const frames = 5
+
+	t.Run("drop", func(t *testing.T) {
+		sink := newGatedSink()
+		d := NewDebugger(sink, 0)
+		d.SetBackpressure(BackpressureDrop)
+		d.queueLimit = 1
+
+		// The first frame waits in the sink and counts against the queue until it is written, so
+		// every later one is dropped
+		for range frames {
+			d.WriteFrame(frame.Frame{Data: "#"})
+		}
+		close(sink.gate)
+
+		err := d.Close()
+		if err != nil {
+			t.Fatalf("could not close debugger: %v", err)
+		}
+		if got := frameSteps(sink.String()); !slices.Equal(got, []int{1}) {
+			t.Errorf("expected steps [1], got %v", got)
+		}
+		if d.Dropped() != frames-1 {
+			t.Errorf("expected %d dropped frames, got %d", frames-1, d.Dropped())
+		}
+	})
+
+	t.Run("block", func(t *testing.T) {
+		sink := newGatedSink()
+		d := NewDebugger(sink, 0)
+		d.queueLimit = 1
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for range frames {
+				d.WriteFrame(frame.Frame{Data: "#"})
+			}
+		}()
+
+		select {
+		case <-done:
+			t.Fatal("expected the writes to wait for the sink")
+		case <-time.After(50 * time.Millisecond):
+		}
+		close(sink.gate)
+		<-done
+
+		err := d.Close()
+		if err != nil {
+			t.Fatalf("could not close debugger: %v", err)
+		}
+		if got := frameSteps(sink.String()); !slices.Equal(got, []int{1, 2, 3, 4, 5}) {
+			t.Errorf("expected every step, got %v", got)
+		}
+		if d.Dropped() != 0 {
+			t.Errorf("expected no dropped frames, got %d", d.Dropped())
+		}
+	})
+}
+
+func TestDebuggerDetach(t *testing.T) {
+	sink := &MemorySink{}
+	d := NewDebugger(sink, -1)
+	d.SetSampling(Sampling{KeepLast: 1})
+	d.WriteFrame(frame.Frame{Data: "1"})
+	d.WriteFrame(frame.Frame{Data: "2"})
+	d.detach()
+	d.WriteFrame(frame.Frame{Data: "3"})
+	d.WriteString("after detach")
+
+	// The frame held before the detach is still written
+	err := d.Close()
+	if err != nil {
+		t.Fatalf("could not close debugger: %v", err)
+	}
+	if got := frameSteps(sink.String()); !slices.Equal(got, []int{1, 2}) {
+		t.Errorf("expected steps [1 2], got %v", got)
+	}
+	if strings.Contains(sink.String(), "after detach") {
+		t.Error("expected the writes after the detach to be dropped")
+	}
+
+	// Writing to a closed Debugger is dropped as well
+	length := d.Len()
+	d.WriteString("after close")
+	if d.Len() != length {
+		t.Errorf("expected the length to stay %d after Close, got %d", length, d.Len())
+	}
+}
+
+func TestDebuggerError(t *testing.T) {
+	sink := &failingSink{}
+	d := NewDebugger(sink, 0)
+	d.WriteString("a")
+	d.Flush()
+
+	// The first error stops the output, so the writes after it never reach the sink
+	err := d.Close()
+	if err == nil || !strings.Contains(err.Error(), "disk full") {
+		t.Fatalf("expected the sink error, got %v", err)
+	}
+	writes := sink.writes
+	d.WriteString("b")
+	if sink.writes != writes {
+		t.Errorf("expected no writes after the error, got %d", sink.writes-writes)
+	}
+	if again := d.Close(); again == nil || again.Error() != err.Error() {
+		t.Errorf("expected Close to return %v again, got %v", err, again)
+	}
+}
+
+func TestDebuggerConcurrentWrites(t *testing.T) {
+	sink := &MemorySink{}
+	d := NewDebugger(sink, 0)
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				d.WriteFrame(frame.Frame{Data: "#"})
+				d.At("step", Trace).WriteString("text\n")
+			}
+		}()
+	}
+	wg.Wait()
+
+	err := d.Close()
+	if err != nil {
+		t.Fatalf("could not close debugger: %v", err)
+	}
+	steps := frameSteps(sink.String())
+	if len(steps) != 800 || !slices.IsSorted(steps) {
+		t.Errorf("expected 800 frames in order, got %d", len(steps))
+	}
+}
@@ -0,0 +1,106 @@
+package aoc
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"main/frame"
+)
+
+// Grid is a step of a part working on a grid, written with WriteGrid so every grid day looks the
+// same in the visualizer
+type Grid struct {
+	Meta string
+	// Cells are the rows of the grid
+	Cells [][]rune
+	// Layers are shown next to the cells or drawn over them
+	Layers     []Layer
+	Highlights []Highlight
+}
+
+// Layer is a named grid of the same size as the cells, its spaces and zero runes are transparent
+// when it is drawn over them
+type Layer struct {
+	Name  string
+	Cells [][]rune
+}
+
+// Highlight colors a cell and can label it
+type Highlight struct {
+	X, Y int
+	// Color is one of the AnsiColor codes
+	Color string
+	Label string
+}
+
+// NewCells returns the rows of a grid filled with r
+func NewCells(width, height int, r rune) [][]rune {
+	cells := make([][]rune, height)
+	for y := range cells {
+		cells[y] = []rune(strings.Repeat(string(r), width))
+	}
+	return cells
+}
+
+// WriteGrid writes the grid as the next step, the grid is only built when the step is kept
+func (d *Debugger) WriteGrid(f func() Grid) {
+	d.WriteFrameFunc(func() frame.Frame { return f().Frame() })
+}
+
+// Frame returns the grid as a frame with the highlights as styles and annotations
+func (g Grid) Frame() frame.Frame {
+	f := frame.Frame{Meta: g.Meta, Height: len(g.Cells)}
+
+	// Byte offset of every row in Data for the styles
+	rowOffsets := make([]int, len(g.Cells))
+	var data strings.Builder
+	for y, row := range g.Cells {
+		if y > 0 {
+			data.WriteByte('\n')
+		}
+		rowOffsets[y] = data.Len()
+		data.WriteString(string(row))
+		f.Width = max(f.Width, len(row))
+	}
+	f.Data = data.String()
+
+	for _, layer := range g.Layers {
+		f.Layers = append(f.Layers, frame.Layer{Name: layer.Name, Data: layerData(layer.Cells)})
+	}
+
+	for _, h := range g.Highlights {
+		if h.Y < 0 || h.Y >= len(g.Cells) || h.X < 0 || h.X >= len(g.Cells[h.Y]) {
+			continue
+		}
+
+		row := g.Cells[h.Y]
+		if color := frame.AnsiColor(h.Color); color != "" {
+			f.Styles = append(f.Styles, frame.Style{
+				Offset: rowOffsets[h.Y] + len(string(row[:h.X])),
+				Length: utf8.RuneLen(row[h.X]),
+				Color:  color,
+			})
+		}
+		if h.Label != "" {
+			f.Annotations = append(f.Annotations, frame.Annotation{X: h.X, Y: h.Y, Label: h.Label})
+		}
+	}
+
+	return f
+}
+
+func layerData(cells [][]rune) string {
+	var data strings.Builder
+	for y, row := range cells {
+		if y > 0 {
+			data.WriteByte('\n')
+		}
+		for _, r := range row {
+			if r == 0 {
+				r = ' '
+			}
+			data.WriteRune(r)
+		}
+	}
+	return data.String()
+}
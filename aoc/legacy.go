@@ -0,0 +1,65 @@
+package aoc
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// The adapters below wrap the part shapes of the older days into a PartFunc, so those days run
+// with the same flags, timing and answer checking without touching their solutions.
+
+// DebugString wraps a part that returns its debug output as a string instead of writing it to a
+// Debugger, e.g. func Part1(input string) (any, string, error)
+func DebugString(f func(string) (any, string, error)) PartFunc {
+	return func(ctx context.Context, input string, debug *Debugger) (any, error) {
+		result, debugOutput, err := f(input)
+		debug.WriteString(debugOutput)
+		return result, err
+	}
+}
+
+// InputVar wraps a part that reads its input from a package variable, which used to be filled by
+// //go:embed. The variable is set to the input before the part runs so -input works for it too.
+func InputVar(input *string, f func() (any, error)) PartFunc {
+	return func(ctx context.Context, in string, debug *Debugger) (any, error) {
+		unlock, err := lockInputVar(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+		defer unlock()
+
+		*input = in
+		return f()
+	}
+}
+
+// InputVarDebugString is InputVar for a part that also returns its debug output as a string
+func InputVarDebugString(input *string, f func() (any, string, error)) PartFunc {
+	return func(ctx context.Context, in string, debug *Debugger) (any, error) {
+		unlock, err := lockInputVar(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+		defer unlock()
+
+		*input = in
+		return DebugString(func(string) (any, string, error) { return f() })(ctx, in, debug)
+	}
+}
+
+// inputVarLocks hold a channel per input variable that is full while a part reads the variable
+var inputVarLocks sync.Map
+
+// lockInputVar waits until no other part reads the input variable. These parts ignore ctx, so a
+// cancelled part can be left running and the next part must not set the variable under it. That
+// part waits until its own ctx is done instead.
+func lockInputVar(ctx context.Context, input *string) (func(), error) {
+	lock, _ := inputVarLocks.LoadOrStore(input, make(chan struct{}, 1))
+	select {
+	case lock.(chan struct{}) <- struct{}{}:
+		return func() { <-lock.(chan struct{}) }, nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("input is still read by a part that was left running: %w", ctx.Err())
+	}
+}
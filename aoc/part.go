@@ -0,0 +1,140 @@
+package aoc
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"path"
+	"regexp"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var dayDirRegex = regexp.MustCompile(`(\d{4})[/\\]day(\d+)[/\\][^/\\]+$`)
+
+// Part is a solution registered by a day. Alternate implementations of a part are registered
+// with a suffix, e.g. "Part2-bruteforce", and are checked against the answers of "Part2".
+type Part struct {
+	Name string
+	// Year and Day default to the <YEAR>/day<DAY> directory of the file calling Register
+	Year     int
+	Day      int
+	Fn       PartFunc
+	Examples []Example
+	Tags     []string
+}
+
+// ExampleInputGlob matches the example input files of a day
+const ExampleInputGlob = "input-example-*.txt"
+
+// Example is an example input file of a part and its expected answer
+type Example struct {
+	Input string
+	Want  any
+}
+
+var registry []Part
+
+// Register adds a part to the ones run by Main. It is meant to be called from init() and panics
+// when the part is invalid or already registered.
+func Register(part Part) {
+	if part.Name == "" || part.Fn == nil {
+		panic(fmt.Errorf("part %#v needs a name and a func", part.Name))
+	}
+
+	if slices.ContainsFunc(registry, func(p Part) bool { return p.Name == part.Name }) {
+		panic(fmt.Errorf("part %s is already registered", part.Name))
+	}
+
+	if part.Year == 0 || part.Day == 0 {
+		_, file, _, _ := runtime.Caller(1)
+		if match := dayDirRegex.FindStringSubmatch(file); match != nil {
+			year, _ := strconv.Atoi(match[1])
+			day, _ := strconv.Atoi(match[2])
+			part.Year = cmp.Or(part.Year, year)
+			part.Day = cmp.Or(part.Day, day)
+		}
+	}
+
+	registry = append(registry, part)
+}
+
+// Parts returns the registered parts in the order they were registered
+func Parts() []Part {
+	return slices.Clone(registry)
+}
+
+// AnswerName is the name the answers of the part are saved under, which is shared by all
+// implementations of the part.
+func (p Part) AnswerName() string {
+	name, _, _ := strings.Cut(p.Name, "-")
+	return name
+}
+
+// Matches reports whether the part is selected by the filter. The filter is an exact name, a glob
+// like "Part2*" or just the number of the part. An empty filter selects every part.
+func (p Part) Matches(filter string) bool {
+	if filter == "" || filter == p.Name {
+		return true
+	}
+
+	if _, err := strconv.Atoi(filter); err == nil {
+		return p.Name == "Part"+filter
+	}
+
+	ok, _ := path.Match(filter, p.Name)
+	return ok
+}
+
+func (p Part) HasTag(tag string) bool {
+	return tag == "" || slices.Contains(p.Tags, tag)
+}
+
+// DebugFile is where the debug output of the part is written. The input name is only given when
+// a run has several inputs.
+func (p Part) DebugFile(inputName string) string {
+	return p.fileName("debug", inputName)
+}
+
+// OutputFile is where the result of the part is written, see DebugFile
+func (p Part) OutputFile(inputName string) string {
+	return p.fileName("output", inputName)
+}
+
+func (p Part) fileName(kind, inputName string) string {
+	if inputName == "" {
+		return fmt.Sprintf("%s-%s.txt", kind, p.Name)
+	}
+	return fmt.Sprintf("%s-%s-%s.txt", kind, p.Name, inputName)
+}
+
+// AllExamples combines the examples of the part with the example inputs answered in answers.json
+func (p Part) AllExamples(answers Answers) []Example {
+	examples := slices.Clone(p.Examples)
+	for input, parts := range answers {
+		if ok, _ := path.Match(ExampleInputGlob, input); !ok {
+			continue
+		}
+
+		want, ok := parts[p.AnswerName()]
+		if !ok || slices.ContainsFunc(examples, func(e Example) bool { return e.Input == input }) {
+			continue
+		}
+
+		examples = append(examples, Example{Input: input, Want: want})
+	}
+
+	slices.SortFunc(examples, func(a, b Example) int {
+		return strings.Compare(a.Input, b.Input)
+	})
+	return examples
+}
+
+// Run runs the part like the runner does: it returns when ctx is done and a panic is returned as a
+// *PanicError
+func (p Part) Run(ctx context.Context, input string, debug *Debugger) (any, error) {
+	result, _, err := runPart(ctx, p.Fn, input, debug)
+	return result, err
+}
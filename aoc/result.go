@@ -0,0 +1,58 @@
+package aoc
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Result is the machine readable record of one part run, written as a JSON line with -format json
+type Result struct {
+	Year        int           `json:"year"`
+	Day         int           `json:"day"`
+	Part        string        `json:"part"`
+	Input       string        `json:"input"`
+	InputSHA256 string        `json:"input_sha256"`
+	Result      any           `json:"result,omitempty"`
+	Duration    time.Duration `json:"duration_ns"`
+	// Allocs and Bytes are allocated by the whole process while the part ran
+	Allocs uint64      `json:"allocs"`
+	Bytes  uint64      `json:"bytes"`
+	Check  CheckStatus `json:"check,omitempty"`
+	Error  string      `json:"error,omitempty"`
+	Debug  string      `json:"debug,omitempty"`
+	// DebugError is why the debug output is incomplete, the result is still valid
+	DebugError string       `json:"debug_error,omitempty"`
+	Bench      *BenchResult `json:"bench,omitempty"`
+}
+
+// ResultWriter writes results as JSON lines, it does nothing when it has no writer
+type ResultWriter struct {
+	encoder *json.Encoder
+}
+
+func NewResultWriter(w io.Writer) *ResultWriter {
+	if w == nil {
+		return &ResultWriter{}
+	}
+	return &ResultWriter{encoder: json.NewEncoder(w)}
+}
+
+func (w *ResultWriter) Write(result Result) error {
+	if w.encoder == nil {
+		return nil
+	}
+
+	err := w.encoder.Encode(result)
+	if err != nil {
+		return fmt.Errorf("could not encode result: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,519 @@
+package aoc
+
+import (
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"main/frame"
+)
+
+// partStopGrace is how long a cancelled part gets to return before the runner stops waiting for it
+const partStopGrace = 2 * time.Second
+
+// Exit codes of a day are flags, so one run can report several kinds of problems at once. 2 is
+// skipped since the flag package uses it for bad arguments.
+const (
+	ExitFailed   = 1 << 0
+	ExitPanicked = 1 << 2
+	ExitMismatch = 1 << 3
+)
+
+// PartFunc is the signature of a part. Long running parts should return when ctx is done.
+type PartFunc func(ctx context.Context, input string, debug *Debugger) (any, error)
+
+// PanicError is returned for a part that panicked
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+var (
+	// Verbose turns on the debug file of every part, set by -v or -debug
+	Verbose bool
+	// WriteOutput writes the result of every part to output-<PART>.txt, set by -o
+	WriteOutput bool
+)
+
+// Main is the main() of a day. It parses the flags, reads the input and runs every registered part
+// that matches the part filter. Days can register their own flags before calling it.
+func Main() {
+	os.Exit(run(Parts()))
+}
+
+func run(parts []Part) int {
+	var inputPatterns []string
+	var partFilter, tagFilter, baselinePath, format, debugSinks, debugCompress string
+	var benchRuns, debugMaxMB int
+	var sampling Sampling
+	var debugFilter DebugFilter
+	var backpressure Backpressure
+	var saveBaseline bool
+	var timeout time.Duration
+	flag.Func("input", "input file or glob, can be repeated (default input.txt)", func(s string) error {
+		inputPatterns = append(inputPatterns, s)
+		return nil
+	})
+	flag.StringVar(&partFilter, "part", "", "only run parts with this name, glob or number")
+	flag.StringVar(&tagFilter, "tag", "", "only run parts with this tag")
+	flag.BoolVar(&Verbose, "v", Verbose, "verbose debug, the same as -debug=*")
+	flag.Func("debug", "comma separated debug categories with an optional level like parse,step:trace", func(s string) error {
+		filter, err := ParseDebugFilter(s)
+		if err != nil {
+			return err
+		}
+		debugFilter = filter
+		return nil
+	})
+	flag.StringVar(&debugSinks, "debug-sink", "file", "comma separated sinks of the verbose debug output: file, stdout, socket or discard, each with its own categories like stdout=step+parse:trace")
+	flag.StringVar(&debugCompress, "debug-compress", frame.CompressNone, "compress the debug file with gzip or zstd")
+	flag.IntVar(&sampling.Every, "debug-every", 0, "only keep every Nth debug frame")
+	flag.Float64Var(&sampling.Rate, "debug-rate", 0, "keep at most this many debug frames per second")
+	flag.IntVar(&debugMaxMB, "debug-max-mb", 0, "stop writing debug output once it is this many MB")
+	flag.IntVar(&sampling.KeepLast, "debug-keep-last", 0, "only keep the first and the last K debug frames")
+	flag.Func("debug-backpressure", "block or drop debug output when the sink falls behind (default block)", func(s string) error {
+		switch s {
+		case "block":
+			backpressure = BackpressureBlock
+		case "drop":
+			backpressure = BackpressureDrop
+		default:
+			return fmt.Errorf("unknown backpressure %#v", s)
+		}
+		return nil
+	})
+	flag.BoolVar(&WriteOutput, "o", WriteOutput, "write output file")
+	flag.IntVar(&benchRuns, "bench", 0, "benchmark every part this many times after a warm-up run")
+	flag.StringVar(&baselinePath, "baseline", BaselineFile, "benchmark baseline to compare against")
+	flag.BoolVar(&saveBaseline, "save-baseline", false, "replace the benchmark baseline with this run")
+	flag.DurationVar(&timeout, "timeout", 0, "cancel every part that runs longer than this")
+	flag.StringVar(&format, "format", FormatText, "also write a JSON line per part to stdout with json")
+	flag.Parse()
+	sampling.MaxBytes = debugMaxMB * 1024 * 1024
+	if _, ok := debugFilter[AllCategories]; Verbose && !ok {
+		if debugFilter == nil {
+			debugFilter = DebugFilter{}
+		}
+		debugFilter[AllCategories] = Debug
+	}
+	Verbose = len(debugFilter) > 0
+
+	sinkSpecs, err := ParseSinks(debugSinks)
+	if err != nil {
+		slog.Error("invalid debug sink", "err", err)
+		return ExitFailed
+	}
+
+	var results *ResultWriter
+	switch format {
+	case FormatText:
+		results = NewResultWriter(nil)
+	case FormatJSON:
+		// Some parts print their progress, keep stdout for the results
+		results = NewResultWriter(os.Stdout)
+		os.Stdout = os.Stderr
+	default:
+		slog.Error("unknown format", "format", format)
+		return ExitFailed
+	}
+
+	inputPaths, err := expandInputs(inputPatterns)
+	if err != nil {
+		slog.Error("could not expand inputs", "err", err)
+		return ExitFailed
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	go func() {
+		// A second interrupt kills the process like before
+		<-ctx.Done()
+		stop()
+	}()
+
+	answers, err := LoadAnswers(".")
+	if err != nil {
+		slog.Error("could not load answers", "err", err)
+		return ExitFailed
+	}
+
+	var baseline Baseline
+	if benchRuns > 0 {
+		baseline, err = LoadBaseline(baselinePath)
+		if err != nil {
+			slog.Error("could not load benchmark baseline", "path", baselinePath, "err", err)
+			return ExitFailed
+		}
+	}
+	baselineChanged := false
+
+	table := inputTable{}
+
+	exitCode := 0
+inputs:
+	for _, inputPath := range inputPaths {
+		inputData, err := os.ReadFile(inputPath)
+		if err != nil {
+			slog.Error("could not read file", "path", inputPath, "err", err)
+			exitCode |= ExitFailed
+			continue
+		}
+
+		inputHash := sha256.Sum256(inputData)
+		input := strings.TrimSuffix(strings.ReplaceAll(string(inputData), "\r\n", "\n"), "\n")
+
+		// With several inputs the files of a part are named after the input so they don't
+		// overwrite each other
+		var inputName string
+		if len(inputPaths) > 1 {
+			inputName = strings.TrimSuffix(filepath.Base(inputPath), filepath.Ext(inputPath))
+		}
+
+		for _, part := range parts {
+			if ctx.Err() != nil {
+				break inputs
+			}
+
+			if !part.Matches(partFilter) || !part.HasTag(tagFilter) {
+				continue
+			}
+
+			// Benchmarks never write debug output, the warm-up run is the one that gets reported
+			debugActive := Verbose && benchRuns == 0
+			debugFile := part.DebugFile(inputName)
+			// A sink that can't be opened leaves the part without debug output and the error is
+			// reported with its result
+			debug := NewDebugger(Discard, -1)
+			if debugActive {
+				debug = OpenDebugger(sinkSpecs, debugFilter, debugFile, debugCompress)
+			}
+			debug.SetSampling(sampling)
+			debug.SetBackpressure(backpressure)
+
+			partCtx, cancel := ctx, context.CancelFunc(func() {})
+			if timeout > 0 {
+				partCtx, cancel = context.WithTimeout(ctx, timeout)
+			}
+
+			var before, after runtime.MemStats
+			runtime.ReadMemStats(&before)
+			start := time.Now()
+			result, stopped, err := runPart(partCtx, part.Fn, input, debug)
+			duration := stopped.Sub(start)
+			runtime.ReadMemStats(&after)
+			ctxErr := partCtx.Err()
+			cancel()
+
+			record := Result{
+				Year:        part.Year,
+				Day:         part.Day,
+				Part:        part.Name,
+				Input:       inputPath,
+				InputSHA256: hex.EncodeToString(inputHash[:]),
+				Duration:    duration,
+				Allocs:      after.Mallocs - before.Mallocs,
+				Bytes:       after.TotalAlloc - before.TotalAlloc,
+			}
+			if debugActive {
+				record.Debug = debugFile + frame.CompressExt(debugCompress)
+			}
+			writeResult := func() {
+				table.add(record)
+
+				err := results.Write(record)
+				if err != nil {
+					slog.Error("could not write result", "func", part.Name, "input", inputPath, "err", err)
+					exitCode |= ExitFailed
+				}
+			}
+			// A part with broken debug output still gets its result, with a warning
+			closeDebug := func() {
+				err := debug.Close()
+				if err != nil {
+					slog.Warn("could not write debug output", "func", part.Name, "input", inputPath, "err", err)
+					record.DebugError = err.Error()
+				}
+				if dropped := debug.Dropped(); dropped > 0 {
+					slog.Warn("dropped debug frames", "func", part.Name, "input", inputPath, "frames", dropped)
+				}
+			}
+
+			if ctxErr != nil {
+				closeDebug()
+
+				msg := "part cancelled"
+				if errors.Is(ctxErr, context.DeadlineExceeded) {
+					msg = "part timed out"
+				}
+				slog.Error(msg, "func", part.Name, "input", inputPath, "duration", duration)
+				exitCode |= ExitFailed
+
+				record.Error = ctxErr.Error()
+				writeResult()
+				continue
+			}
+
+			var panicErr *PanicError
+			if errors.As(err, &panicErr) {
+				closeDebug()
+
+				panicFile := PanicFile(debugFile, debugCompress)
+				writeErr := writePanic(panicFile, panicErr)
+				if writeErr != nil {
+					slog.Error("could not write panic to debug file", "func", part.Name, "input", inputPath, "err", writeErr)
+				}
+				slog.Error("part panicked", "func", part.Name, "input", inputPath, "panic", panicErr.Value, "debug", panicFile, "stack", string(panicErr.Stack))
+				exitCode |= ExitPanicked
+
+				record.Error = panicErr.Error()
+				if !debugActive {
+					record.Debug = panicFile
+				}
+				writeResult()
+				continue
+			}
+
+			if err != nil {
+				closeDebug()
+
+				slog.Error("could not run part", "func", part.Name, "input", inputPath, "err", err)
+				exitCode |= ExitFailed
+
+				record.Error = err.Error()
+				writeResult()
+				continue
+			}
+
+			if WriteOutput {
+				err := os.WriteFile(part.OutputFile(inputName), []byte(fmt.Sprintf("%v", result)), 0777)
+				if err != nil {
+					slog.Error("could not write result", "func", part.Name, "input", inputPath, "result", result, "err", err)
+					exitCode |= ExitFailed
+				}
+			}
+
+			check := answers.Check(inputPath, part.AnswerName(), result)
+			if check.Status == CheckFail {
+				exitCode |= ExitMismatch
+			}
+
+			closeDebug()
+			slog.Info("finished running part", "func", part.Name, "input", inputPath, "duration", duration, "result", result, "check", check)
+
+			if benchRuns > 0 {
+				// Bench runs are run like the part itself, a failing one stops the benchmark
+				bench, err := Bench(benchRuns, func() error {
+					benchCtx, cancel := ctx, context.CancelFunc(func() {})
+					if timeout > 0 {
+						benchCtx, cancel = context.WithTimeout(ctx, timeout)
+					}
+					defer cancel()
+
+					_, _, err := runPart(benchCtx, part.Fn, input, NewDebugger(Discard, -1))
+					if benchCtx.Err() != nil {
+						return benchCtx.Err()
+					}
+					return err
+				})
+				if err != nil {
+					var panicErr *PanicError
+					if errors.As(err, &panicErr) {
+						slog.Error("could not benchmark part", "func", part.Name, "input", inputPath, "err", err, "stack", string(panicErr.Stack))
+						exitCode |= ExitPanicked
+					} else {
+						slog.Error("could not benchmark part", "func", part.Name, "input", inputPath, "err", err)
+						exitCode |= ExitFailed
+					}
+
+					record.Error = "benchmark: " + err.Error()
+				} else {
+					slog.Info("finished benchmarking part", append([]any{"func", part.Name, "input", inputPath}, bench.LogAttrs()...)...)
+
+					baselineKey := BaselineKey(part.Name, inputPath)
+
+					if previous, ok := baseline[baselineKey]; ok {
+						slog.Info("compared to baseline", append([]any{"func", part.Name, "input", inputPath}, bench.Compare(previous).LogAttrs()...)...)
+					}
+
+					if _, ok := baseline[baselineKey]; !ok || saveBaseline {
+						baseline[baselineKey] = bench
+						baselineChanged = true
+					}
+
+					record.Bench = &bench
+				}
+			}
+
+			record.Result = result
+			record.Check = check.Status
+			writeResult()
+		}
+	}
+
+	if baselineChanged {
+		err := baseline.Save(baselinePath)
+		if err != nil {
+			slog.Error("could not save benchmark baseline", "path", baselinePath, "err", err)
+			exitCode |= ExitFailed
+		}
+	}
+
+	if len(inputPaths) > 1 && format == FormatText {
+		table.print(os.Stdout)
+	}
+
+	return exitCode
+}
+
+// expandInputs turns the -input values into paths, in the order they were given. A value that
+// matches no file is kept so reading it reports the missing file.
+func expandInputs(patterns []string) ([]string, error) {
+	if len(patterns) == 0 {
+		return []string{"input.txt"}, nil
+	}
+
+	var paths []string
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("could not match %#v: %w", pattern, err)
+		}
+
+		if len(matches) == 0 {
+			matches = []string{pattern}
+		}
+
+		for _, match := range matches {
+			if !slices.Contains(paths, match) {
+				paths = append(paths, match)
+			}
+		}
+	}
+
+	return paths, nil
+}
+
+// inputTable collects the results of every input and part for the summary of a run over several
+// inputs
+type inputTable struct {
+	inputs []string
+	parts  []string
+	cells  map[[2]string]string
+}
+
+func (t *inputTable) add(record Result) {
+	if !slices.Contains(t.inputs, record.Input) {
+		t.inputs = append(t.inputs, record.Input)
+	}
+	if !slices.Contains(t.parts, record.Part) {
+		t.parts = append(t.parts, record.Part)
+	}
+	if t.cells == nil {
+		t.cells = map[[2]string]string{}
+	}
+
+	cell := fmt.Sprintf("%v (%s)", record.Result, record.Duration.Round(time.Microsecond))
+	if record.Error != "" {
+		cell = "error: " + record.Error
+	}
+	t.cells[[2]string{record.Input, record.Part}] = cell
+}
+
+func (t inputTable) print(w io.Writer) {
+	// Parts may have printed their progress without a newline
+	fmt.Fprintln(w)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "INPUT\t"+strings.Join(t.parts, "\t"))
+	for _, input := range t.inputs {
+		fmt.Fprint(tw, input)
+		for _, part := range t.parts {
+			fmt.Fprint(tw, "\t"+cmp.Or(t.cells[[2]string{input, part}], "-"))
+		}
+		fmt.Fprintln(tw)
+	}
+	tw.Flush()
+}
+
+// PanicFile is where the panic of a part is written. That is its debug file, unless the debug file
+// is compressed: a plain file next to it would be opened by the visualizer instead of the frames.
+func PanicFile(debugFile, compression string) string {
+	if compression == frame.CompressNone {
+		return debugFile
+	}
+	return strings.TrimSuffix(debugFile, filepath.Ext(debugFile)) + ".panic.txt"
+}
+
+// writePanic appends a recovered panic and its stack to the debug file, even when debugging is
+// off, so a crashed part always leaves a trace behind.
+func writePanic(path string, panicErr *PanicError) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("could not open file for panic: %w", err)
+	}
+	defer file.Close()
+
+	_, err = fmt.Fprintf(file, "\npanic: %v\n\n%s", panicErr.Value, panicErr.Stack)
+	if err != nil {
+		return fmt.Errorf("could not write panic to file: %w", err)
+	}
+
+	return nil
+}
+
+// runPart runs the part until it returns or ctx is done and returns when it stopped, which is when
+// ctx was done for a cancelled part. A part that ignores ctx is given partStopGrace to return and is
+// then left running with its Debugger detached so the runner can report it. A panic is returned as
+// a *PanicError.
+func runPart(ctx context.Context, f PartFunc, input string, debugger *Debugger) (any, time.Time, error) {
+	type partResult struct {
+		result any
+		err    error
+	}
+
+	done := make(chan partResult, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- partResult{err: &PanicError{Value: r, Stack: debug.Stack()}}
+			}
+		}()
+
+		result, err := f(ctx, input, debugger)
+		done <- partResult{result, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.result, time.Now(), r.err
+	case <-ctx.Done():
+	}
+	cancelled := time.Now()
+
+	select {
+	case r := <-done:
+		return r.result, cancelled, r.err
+	case <-time.After(partStopGrace):
+		debugger.detach()
+		return nil, cancelled, ctx.Err()
+	}
+}
@@ -0,0 +1,91 @@
+package aoc
+
+import (
+	"slices"
+	"time"
+
+	"main/frame"
+)
+
+// Sampling decides which frames a Debugger keeps, so parts can write a frame for every step and
+// leave the size of the output to the command line. The zero value keeps everything.
+type Sampling struct {
+	// Every only keeps every Nth frame
+	Every int
+	// Rate keeps at most this many frames per second
+	Rate float64
+	// MaxBytes stops writing anything once the output is this large
+	MaxBytes int
+	// KeepLast only keeps the last K frames, which are written on Close
+	KeepLast int
+}
+
+// The first frame is always kept so the visualizer has the starting point
+type sampler struct {
+	Sampling
+	lastKept time.Time
+	ring     []frame.Frame
+	ringNext int
+}
+
+// skip reports whether the frame with the step number is dropped by Every or Rate
+func (s *sampler) skip(step int) bool {
+	if step == 1 {
+		s.lastKept = time.Now()
+		return false
+	}
+
+	if s.Every > 1 && (step-1)%s.Every != 0 {
+		return true
+	}
+
+	if s.Rate > 0 {
+		now := time.Now()
+		if now.Sub(s.lastKept) < time.Duration(float64(time.Second)/s.Rate) {
+			return true
+		}
+		s.lastKept = now
+	}
+
+	return false
+}
+
+// hold keeps the frame for Close instead of writing it now when only the last frames are kept
+func (s *sampler) hold(f frame.Frame) bool {
+	if s.KeepLast <= 0 || f.Step == 1 {
+		return false
+	}
+
+	if len(s.ring) < s.KeepLast {
+		s.ring = append(s.ring, f)
+	} else {
+		s.ring[s.ringNext] = f
+	}
+	s.ringNext = (s.ringNext + 1) % s.KeepLast
+	return true
+}
+
+// held returns the held frames in the order they were written
+func (s *sampler) held() []frame.Frame {
+	if len(s.ring) < s.KeepLast {
+		return s.ring
+	}
+	return slices.Concat(s.ring[s.ringNext:], s.ring[:s.ringNext])
+}
+
+// SetSampling changes which frames are kept from now on
+func (d *Debugger) SetSampling(sampling Sampling) {
+	for _, t := range d.tee {
+		t.SetSampling(sampling)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.sampler.Sampling = sampling
+}
+
+// full reports whether the output reached the MaxBytes of the sampling, it must be called with the
+// lock held
+func (d *Debugger) full() bool {
+	return d.sampler.MaxBytes > 0 && d.len() >= d.sampler.MaxBytes
+}
@@ -0,0 +1,376 @@
+package aoc
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"main/frame"
+)
+
+// Sink receives the debug output of a part every time the Debugger flushes
+type Sink interface {
+	io.Writer
+	Close() error
+}
+
+// Discard is the sink of an inactive Debugger, nothing is ever written to it
+var Discard Sink = discardSink{}
+
+type discardSink struct{}
+
+func (discardSink) Write(p []byte) (int, error) { return len(p), nil }
+func (discardSink) Close() error                { return nil }
+
+// FileSink writes to a file, which is truncated when the sink is created
+type FileSink struct {
+	file *os.File
+}
+
+func NewFileSink(path string) (*FileSink, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("could not open debug file: %w", err)
+	}
+	return &FileSink{file: file}, nil
+}
+
+func (s *FileSink) Write(p []byte) (int, error) {
+	return s.file.Write(p)
+}
+
+func (s *FileSink) Close() error {
+	return s.file.Close()
+}
+
+// CompressSink compresses everything written to a sink. It flushes after every write, so a reader
+// can decompress everything but the last block while the file is still written.
+type CompressSink struct {
+	sink       Sink
+	compressor frame.Compressor
+}
+
+func NewCompressSink(sink Sink, compression string) (*CompressSink, error) {
+	compressor, err := frame.NewCompressor(sink, compression)
+	if err != nil {
+		return nil, err
+	}
+	return &CompressSink{sink: sink, compressor: compressor}, nil
+}
+
+func (s *CompressSink) Write(p []byte) (int, error) {
+	n, err := s.compressor.Write(p)
+	if err != nil {
+		return n, err
+	}
+	return n, s.compressor.Flush()
+}
+
+func (s *CompressSink) Close() error {
+	return errors.Join(s.compressor.Close(), s.sink.Close())
+}
+
+// WriterSink writes to a writer it doesn't own, like os.Stdout, so closing it does nothing
+type WriterSink struct {
+	io.Writer
+}
+
+func (WriterSink) Close() error { return nil }
+
+// MemorySink keeps everything in memory so tests can check the debug output of a part
+type MemorySink struct {
+	bytes.Buffer
+}
+
+func (*MemorySink) Close() error { return nil }
+
+// MultiSink writes the same output to every sink, e.g. a file and a console view. Sinks with
+// filters of their own get a Debugger each, see OpenDebugger.
+type MultiSink []Sink
+
+func (m MultiSink) Write(p []byte) (int, error) {
+	for _, sink := range m {
+		_, err := sink.Write(p)
+		if err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+func (m MultiSink) Close() error {
+	var errs []error
+	for _, sink := range m {
+		errs = append(errs, sink.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// StripAnsiSink removes the ANSI color codes before writing to a sink that isn't a terminal
+type StripAnsiSink struct {
+	Sink
+}
+
+func (s StripAnsiSink) Write(p []byte) (int, error) {
+	_, err := s.Sink.Write([]byte(stripAnsi(string(p))))
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// socketAttachTimeout is how long the first write to a SocketSink waits for a visualizer
+const socketAttachTimeout = 10 * time.Second
+
+// SocketSink streams the debug output to every visualizer attached to a unix socket. The first
+// write waits up to socketAttachTimeout for a visualizer so it sees the run from the start. Later
+// ones only get what is written after they attach and without a visualizer the output is dropped.
+type SocketSink struct {
+	listener net.Listener
+	attached chan struct{}
+	once     sync.Once
+
+	mu    sync.Mutex
+	conns []net.Conn
+}
+
+func NewSocketSink(path string) (*SocketSink, error) {
+	// A socket left behind by a killed run would make listening fail
+	os.Remove(path)
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("could not listen on debug socket: %w", err)
+	}
+
+	s := &SocketSink{listener: listener, attached: make(chan struct{})}
+	go s.accept()
+	time.AfterFunc(socketAttachTimeout, func() {
+		s.once.Do(func() {
+			slog.Warn("no visualizer attached, dropping debug output until one does", "socket", path)
+			close(s.attached)
+		})
+	})
+
+	slog.Info("waiting for the visualizer to attach", "socket", path)
+	return s, nil
+}
+
+func (s *SocketSink) accept() {
+	defer s.once.Do(func() { close(s.attached) })
+
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		s.mu.Lock()
+		s.conns = append(s.conns, conn)
+		s.mu.Unlock()
+		s.once.Do(func() { close(s.attached) })
+	}
+}
+
+// Write sends to every attached visualizer, one that went away is dropped
+func (s *SocketSink) Write(p []byte) (int, error) {
+	<-s.attached
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.conns = slices.DeleteFunc(s.conns, func(conn net.Conn) bool {
+		_, err := conn.Write(p)
+		if err != nil {
+			conn.Close()
+			return true
+		}
+		return false
+	})
+
+	return len(p), nil
+}
+
+func (s *SocketSink) Close() error {
+	err := s.listener.Close()
+	s.once.Do(func() { close(s.attached) })
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, conn := range s.conns {
+		conn.Close()
+	}
+	s.conns = nil
+
+	return err
+}
+
+// isLive reports whether the sink should get every write right away instead of once the
+// Debugger buffer is full
+func isLive(sink Sink) bool {
+	switch sink := sink.(type) {
+	case *SocketSink:
+		return true
+	case MultiSink:
+		return slices.ContainsFunc(sink, isLive)
+	case StripAnsiSink:
+		return isLive(sink.Sink)
+	}
+	return false
+}
+
+// SocketPath is the socket a part streams its debug output on, next to its debug file
+func SocketPath(debugFile string) string {
+	return strings.TrimSuffix(debugFile, filepath.Ext(debugFile)) + ".sock"
+}
+
+// sinkNames are the sinks OpenSinks knows
+var sinkNames = []string{"file", "stdout", "socket", "discard", ""}
+
+// SinkSpec is a sink as given to -debug-sink. Filter is nil for a sink that writes the categories
+// of -debug.
+type SinkSpec struct {
+	Name   string
+	Filter DebugFilter
+}
+
+// ParseSinks parses a comma separated list of sinks as given to -debug-sink. A sink can have
+// categories of its own after =, separated by + since the sinks are separated by commas, e.g.
+// "file,stdout=step+parse:trace".
+func ParseSinks(s string) ([]SinkSpec, error) {
+	var specs []SinkSpec
+	for _, entry := range strings.Split(s, ",") {
+		name, categories, found := strings.Cut(strings.TrimSpace(entry), "=")
+		if !slices.Contains(sinkNames, name) {
+			return nil, fmt.Errorf("unknown debug sink %#v", name)
+		}
+		if name != "" && slices.ContainsFunc(specs, func(spec SinkSpec) bool { return spec.Name == name }) {
+			return nil, fmt.Errorf("debug sink %s is given twice", name)
+		}
+
+		spec := SinkSpec{Name: name}
+		if found {
+			filter, err := ParseDebugFilter(strings.ReplaceAll(categories, "+", ","))
+			if err != nil {
+				return nil, fmt.Errorf("could not parse categories of debug sink %s: %w", name, err)
+			}
+			spec.Filter = filter
+		}
+		specs = append(specs, spec)
+	}
+	return specs, nil
+}
+
+// OpenDebugger opens the sinks for the debug file at path and returns a Debugger with the filter
+// for them. A sink with a filter of its own gets a Debugger of its own and they are written
+// through a tee. When a sink can't be opened the Debugger is inactive and returns the error on
+// Close.
+func OpenDebugger(specs []SinkSpec, filter DebugFilter, path, compression string) *Debugger {
+	var debuggers []*Debugger
+	open := func(specs []SinkSpec, filter DebugFilter) error {
+		sink, err := OpenSinks(specs, path, compression)
+		if err != nil {
+			return err
+		}
+		if sink != Discard {
+			d := NewDebugger(sink, -1)
+			d.SetFilter(filter)
+			debuggers = append(debuggers, d)
+		}
+		return nil
+	}
+
+	var shared []SinkSpec
+	for _, spec := range specs {
+		if spec.Filter == nil {
+			shared = append(shared, spec)
+		}
+	}
+	err := open(shared, filter)
+	for _, spec := range specs {
+		if err == nil && spec.Filter != nil {
+			err = open([]SinkSpec{spec}, spec.Filter)
+		}
+	}
+	if err != nil {
+		for _, d := range debuggers {
+			d.Close()
+		}
+		d := NewDebugger(Discard, -1)
+		d.setErr(err)
+		return d
+	}
+
+	switch len(debuggers) {
+	case 0:
+		return NewDebugger(Discard, -1)
+	case 1:
+		return debuggers[0]
+	}
+	return NewTeeDebugger(debuggers...)
+}
+
+// OpenSinks opens the sinks as given to -debug-sink, without their filters. The file sink writes
+// to path without colors, with the extension of the compression added when there is one. Stdout
+// keeps the colors and socket streams to SocketPath(path).
+func OpenSinks(specs []SinkSpec, path, compression string) (Sink, error) {
+	var sinks MultiSink
+	for _, spec := range specs {
+		switch spec.Name {
+		case "file":
+			sink, err := openFileSink(path, compression)
+			if err != nil {
+				sinks.Close()
+				return nil, err
+			}
+			sinks = append(sinks, StripAnsiSink{sink})
+		case "stdout":
+			sinks = append(sinks, WriterSink{os.Stdout})
+		case "socket":
+			sink, err := NewSocketSink(SocketPath(path))
+			if err != nil {
+				sinks.Close()
+				return nil, err
+			}
+			sinks = append(sinks, StripAnsiSink{sink})
+		case "discard", "":
+		default:
+			sinks.Close()
+			return nil, fmt.Errorf("unknown debug sink %#v", spec.Name)
+		}
+	}
+
+	switch len(sinks) {
+	case 0:
+		return Discard, nil
+	case 1:
+		return sinks[0], nil
+	}
+	return sinks, nil
+}
+
+func openFileSink(path, compression string) (Sink, error) {
+	file, err := NewFileSink(path + frame.CompressExt(compression))
+	if err != nil {
+		return nil, err
+	}
+
+	if compression == frame.CompressNone {
+		return file, nil
+	}
+
+	sink, err := NewCompressSink(file, compression)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return sink, nil
+}
@@ -0,0 +1,91 @@
+package aoc
+
+import (
+	"errors"
+	"slices"
+	"sync"
+)
+
+// teeView is the key of the Debugger a tee hands out for a category at a level
+type teeView struct {
+	category string
+	level    Level
+}
+
+// NewTeeDebugger writes to every debugger, each with its own sink, filter, sampling and
+// backpressure, e.g. a full debug file and a console view of a single category. At only writes to
+// the debuggers that enable the category.
+func NewTeeDebugger(debuggers ...*Debugger) *Debugger {
+	active := slices.ContainsFunc(debuggers, func(d *Debugger) bool { return d.active })
+	return &Debugger{active: active, sink: Discard, tee: debuggers}
+}
+
+// teeAt is At of a tee, a category that only some of the debuggers enable gets a tee of those
+func (d *Debugger) teeAt(category string, level Level) *Debugger {
+	var enabled []*Debugger
+	for _, t := range d.tee {
+		if at := t.At(category, level); at != offDebugger {
+			enabled = append(enabled, at)
+		}
+	}
+	switch len(enabled) {
+	case 0:
+		return offDebugger
+	case len(d.tee):
+		return d
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	key := teeView{category: category, level: level}
+	view, ok := d.views[key]
+	if !ok {
+		view = NewTeeDebugger(enabled...)
+		if d.views == nil {
+			d.views = map[teeView]*Debugger{}
+		}
+		d.views[key] = view
+	}
+	return view
+}
+
+// teeWriteFunc builds the output once for all debuggers of a tee
+func (d *Debugger) teeWriteFunc(f func() string) {
+	f = sync.OnceValue(f)
+	for _, t := range d.tee {
+		t.WriteFunc(f)
+	}
+}
+
+func (d *Debugger) teeClose() error {
+	var errs []error
+	for _, t := range d.tee {
+		errs = append(errs, t.Close())
+	}
+	return errors.Join(errs...)
+}
+
+func (d *Debugger) teeErr() error {
+	var errs []error
+	for _, t := range d.tee {
+		errs = append(errs, t.Err())
+	}
+	return errors.Join(errs...)
+}
+
+func (d *Debugger) teeFlush() error {
+	var errs []error
+	for _, t := range d.tee {
+		errs = append(errs, t.Flush())
+	}
+	return errors.Join(errs...)
+}
+
+// teeSum adds up a count of every debugger of a tee
+func (d *Debugger) teeSum(count func(*Debugger) int) int {
+	n := 0
+	for _, t := range d.tee {
+		n += count(t)
+	}
+	return n
+}
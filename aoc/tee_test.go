@@ -0,0 +1,103 @@
+package aoc
+
+import (
+	"strings"
+	"testing"
+
+	"main/frame"
+)
+
+func TestParseSinks(t *testing.T) {
+	tests := []struct {
+		name  string
+		sinks string
+		want  []SinkSpec
+		err   bool
+	}{
+		{name: "plain", sinks: "file,stdout", want: []SinkSpec{{Name: "file"}, {Name: "stdout"}}},
+		{name: "filter", sinks: "file,stdout=step+parse:trace", want: []SinkSpec{{Name: "file"}, {Name: "stdout", Filter: DebugFilter{"step": Debug, "parse": Trace}}}},
+		{name: "empty filter", sinks: "stdout=", want: []SinkSpec{{Name: "stdout", Filter: DebugFilter{}}}},
+		{name: "unknown sink", sinks: "file,printer", err: true},
+		{name: "unknown level", sinks: "stdout=step:loud", err: true},
+		{name: "twice", sinks: "stdout,stdout=step", err: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ParseSinks(test.sinks)
+			if test.err {
+				if err == nil {
+					t.Fatalf("expected an error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("could not parse sinks: %v", err)
+			}
+			if len(got) != len(test.want) {
+				t.Fatalf("expected %v, got %v", test.want, got)
+			}
+			for i := range got {
+				if got[i].Name != test.want[i].Name || (got[i].Filter == nil) != (test.want[i].Filter == nil) || len(got[i].Filter) != len(test.want[i].Filter) {
+					t.Fatalf("expected %v, got %v", test.want, got)
+				}
+				for category, level := range test.want[i].Filter {
+					if got[i].Filter[category] != level {
+						t.Fatalf("expected %v, got %v", test.want, got)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestTeeDebugger(t *testing.T) {
+	full, console := &MemorySink{}, &MemorySink{}
+	fullDebug, consoleDebug := NewDebugger(full, -1), NewDebugger(console, -1)
+	consoleDebug.SetFilter(DebugFilter{"step": Debug})
+	debug := NewTeeDebugger(fullDebug, consoleDebug)
+
+	built := 0
+	debug.WriteFunc(func() string { built++; return "plain\n" })
+	debug.At("parse", Debug).WriteString("parse\n")
+	debug.At("step", Debug).WriteString("step\n")
+	debug.At("step", Trace).WriteString("trace\n")
+
+	err := debug.Close()
+	if err != nil {
+		t.Fatalf("could not close debugger: %v", err)
+	}
+
+	if built != 1 {
+		t.Errorf("expected the output to be built once, got %d", built)
+	}
+	tests := []struct {
+		name string
+		sink *MemorySink
+		want string
+	}{
+		{name: "full", sink: full, want: "plain\nparse\nstep\ntrace\n"},
+		{name: "console", sink: console, want: "plain\nstep\n"},
+	}
+	for _, test := range tests {
+		if got := test.sink.String(); got != test.want {
+			t.Errorf("%s: expected %q, got %q", test.name, test.want, got)
+		}
+	}
+	if got := debug.Len(); got != len(tests[0].want)+len(tests[1].want) {
+		t.Errorf("expected a length of %d, got %d", len(tests[0].want)+len(tests[1].want), got)
+	}
+}
+
+func TestOpenDebuggerError(t *testing.T) {
+	specs, err := ParseSinks("file,stdout=step")
+	if err != nil {
+		t.Fatalf("could not parse sinks: %v", err)
+	}
+
+	debug := OpenDebugger(specs, nil, t.TempDir()+"/missing/debug-Part1.txt", frame.CompressNone)
+	debug.WriteString("lost\n")
+	err = debug.Close()
+	if err == nil || !strings.Contains(err.Error(), "could not open debug file") {
+		t.Errorf("expected an open error, got %v", err)
+	}
+}
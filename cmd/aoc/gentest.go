@@ -0,0 +1,137 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	exampleTestFile = "examples_test.go"
+	generatedHeader = "// Code generated by aoc gentest. DO NOT EDIT."
+)
+
+// exampleTestSource is the same for every day, the examples are looked up when the test runs
+const exampleTestSource = generatedHeader + `
+
+package main
+
+import (
+	"testing"
+
+	"main/aoc/aoctest"
+)
+
+func TestExamples(t *testing.T) {
+	aoctest.TestExamples(t)
+}
+`
+
+func gentestCommand(root string, arguments []string) int {
+	flags := flag.NewFlagSet("gentest", flag.ExitOnError)
+	flags.Parse(arguments)
+
+	var days []Day
+	if flags.NArg() == 0 {
+		// Called from go:generate inside a day directory
+		dir, err := os.Getwd()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "could not get working directory: %v\n", err)
+			return 1
+		}
+		days = append(days, Day{Dir: dir})
+	} else {
+		var year, day int
+		for i, s := range flags.Args() {
+			n, err := strconv.Atoi(s)
+			if err != nil || i > 1 {
+				fmt.Fprint(os.Stderr, usage)
+				return 2
+			}
+
+			if i == 0 {
+				year = n
+			} else {
+				day = n
+			}
+		}
+
+		var err error
+		days, err = discoverDays(root, year, day)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "could not discover days: %v\n", err)
+			return 1
+		}
+	}
+
+	exitCode := 0
+	for _, d := range days {
+		err := generateExampleTests(d.Dir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "could not generate tests for %s: %v\n", d.Dir, err)
+			exitCode = 1
+		}
+	}
+
+	return exitCode
+}
+
+// generateExampleTests writes a test that runs every registered part of the day against its
+// examples and the example inputs listed in the answers file.
+func generateExampleTests(dir string) error {
+	registered, err := registersParts(dir)
+	if err != nil {
+		return err
+	}
+
+	if !registered {
+		return fmt.Errorf("day does not register its parts with aoc.Register")
+	}
+
+	err = os.WriteFile(filepath.Join(dir, exampleTestFile), []byte(exampleTestSource), 0644)
+	if err != nil {
+		return fmt.Errorf("could not write generated test: %w", err)
+	}
+
+	return nil
+}
+
+// registersParts reports whether the day calls aoc.Register
+func registersParts(dir string) (bool, error) {
+	pkgs, err := parser.ParseDir(token.NewFileSet(), dir, func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go")
+	}, 0)
+	if err != nil {
+		return false, fmt.Errorf("could not parse day: %w", err)
+	}
+
+	registered := false
+	for _, pkg := range pkgs {
+		for _, file := range pkg.Files {
+			ast.Inspect(file, func(n ast.Node) bool {
+				if call, ok := n.(*ast.CallExpr); ok && isSelector(call.Fun, "aoc", "Register") {
+					registered = true
+				}
+				return !registered
+			})
+		}
+	}
+
+	return registered, nil
+}
+
+func isSelector(expr ast.Expr, pkg, name string) bool {
+	sel, ok := expr.(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+
+	ident, ok := sel.X.(*ast.Ident)
+	return ok && ident.Name == pkg && sel.Sel.Name == name
+}
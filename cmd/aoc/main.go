@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+)
+
+const usage = `usage: aoc <command> [arguments]
+
+commands:
+  run [flags] <year> [day] [part]    run every matching day and print a combined report
+  gentest [year] [day]               generate example tests from answers.json, defaults to the current day
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	root, err := findRoot()
+	if err != nil {
+		slog.Error("could not find repository root", "err", err)
+		os.Exit(1)
+	}
+
+	switch os.Args[1] {
+	case "run":
+		os.Exit(runCommand(root, os.Args[2:]))
+	case "gentest":
+		os.Exit(gentestCommand(root, os.Args[2:]))
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+}
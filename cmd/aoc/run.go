@@ -0,0 +1,563 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"main/aoc"
+)
+
+var (
+	yearDirRegex = regexp.MustCompile(`^\d{4}$`)
+	dayDirRegex  = regexp.MustCompile(`^day(\d+)$`)
+)
+
+type Day struct {
+	Year int
+	Day  int
+	Dir  string
+}
+
+type PartResult struct {
+	Day      Day
+	Part     string
+	Input    string
+	Result   string
+	Duration string
+	Err      string
+	Panicked bool
+	// DebugErr is why the debug output of the part is incomplete
+	DebugErr string
+	Check    aoc.Check
+	// Bench and Baseline hold the attributes of the benchmark log lines
+	Bench    map[string]string
+	Baseline map[string]string
+}
+
+type DayRun struct {
+	Day      Day
+	Parts    []PartResult
+	Duration time.Duration
+	Err      error
+	// Results are the JSON lines of the day, only read with -format json
+	Results []aoc.Result
+}
+
+func runCommand(root string, arguments []string) int {
+	var inputPatterns, forwarded []string
+	var partFilter, tagFilter, format, debugCategories string
+	var verboseDebug, saveAnswers bool
+	var benchRuns int
+	var timeout time.Duration
+	flags := flag.NewFlagSet("run", flag.ExitOnError)
+	// forward passes a flag to every day as it was given
+	forward := func(name, usage string) {
+		flags.Func(name, usage, func(s string) error {
+			forwarded = append(forwarded, "-"+name, s)
+			return nil
+		})
+	}
+	forwardBool := func(name, usage string) {
+		flags.BoolFunc(name, usage, func(s string) error {
+			forwarded = append(forwarded, "-"+name+"="+s)
+			return nil
+		})
+	}
+	flags.Func("input", "input file or glob passed to every day, can be repeated", func(s string) error {
+		inputPatterns = append(inputPatterns, s)
+		return nil
+	})
+	flags.StringVar(&partFilter, "part", "", "only run parts with this name, glob or number, the same as the PART argument")
+	flags.StringVar(&tagFilter, "tag", "", "only run parts with this tag")
+	flags.BoolVar(&verboseDebug, "v", false, "verbose debug")
+	flags.StringVar(&debugCategories, "debug", "", "debug categories passed to every day, like parse,step:trace")
+	forward("debug-sink", "sinks of the debug output passed to every day, like file,stdout=step")
+	forward("debug-compress", "compress the debug files with gzip or zstd")
+	forward("debug-every", "only keep every Nth debug frame")
+	forward("debug-rate", "keep at most this many debug frames per second")
+	forward("debug-keep-last", "only keep the first and the last K debug frames")
+	forward("debug-max-mb", "stop writing debug output once it is this many MB")
+	forward("debug-backpressure", "block or drop debug output when the sink falls behind")
+	forwardBool("o", "write the output file of every part")
+	flags.BoolVar(&saveAnswers, "save", false, "save NEW results to the answers file of each day")
+	flags.IntVar(&benchRuns, "bench", 0, "benchmark every part this many times")
+	forward("baseline", "benchmark baseline of every day to compare against")
+	forwardBool("save-baseline", "replace the benchmark baseline of every day with this run")
+	flags.DurationVar(&timeout, "timeout", 0, "cancel every part that runs longer than this")
+	flags.StringVar(&format, "format", aoc.FormatText, "report format, text or json for a JSON line per part")
+	flags.Parse(arguments)
+
+	if format != aoc.FormatText && format != aoc.FormatJSON {
+		fmt.Fprintf(os.Stderr, "unknown format %#v\n", format)
+		return 2
+	}
+
+	if flags.NArg() < 1 || flags.NArg() > 3 {
+		fmt.Fprint(os.Stderr, usage)
+		return 2
+	}
+
+	var year, day int
+	for i, s := range flags.Args() {
+		if i == 2 {
+			partFilter = s
+			break
+		}
+
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "could not parse %#v as a number: %v\n", s, err)
+			return 2
+		}
+
+		if i == 0 {
+			year = n
+		} else {
+			day = n
+		}
+	}
+
+	days, err := discoverDays(root, year, day)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "could not discover days: %v\n", err)
+		return 1
+	}
+
+	if len(days) == 0 {
+		fmt.Fprintf(os.Stderr, "no days found for %s\n", strings.Join(flags.Args(), " "))
+		return 1
+	}
+
+	var dayArgs []string
+	for _, pattern := range inputPatterns {
+		dayArgs = append(dayArgs, "-input", pattern)
+	}
+	if partFilter != "" {
+		dayArgs = append(dayArgs, "-part", partFilter)
+	}
+	if tagFilter != "" {
+		dayArgs = append(dayArgs, "-tag", tagFilter)
+	}
+	if verboseDebug {
+		dayArgs = append(dayArgs, "-v")
+	}
+	if debugCategories != "" {
+		dayArgs = append(dayArgs, "-debug", debugCategories)
+	}
+	if benchRuns > 0 {
+		dayArgs = append(dayArgs, "-bench", strconv.Itoa(benchRuns))
+	}
+	if timeout > 0 {
+		dayArgs = append(dayArgs, "-timeout", timeout.String())
+	}
+	if format == aoc.FormatJSON {
+		dayArgs = append(dayArgs, "-format", aoc.FormatJSON)
+	}
+	dayArgs = append(dayArgs, forwarded...)
+
+	// The running day gets the interrupt as well and reports what it cancelled, so only stop
+	// starting new days here
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	var runs []DayRun
+	for _, d := range days {
+		if ctx.Err() != nil {
+			break
+		}
+
+		fmt.Fprintf(os.Stderr, "running %d/day%d\n", d.Year, d.Day)
+		run := runDay(d, dayArgs)
+
+		err := checkAnswers(&run, saveAnswers)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "could not check answers of %d/day%d: %v\n", d.Year, d.Day, err)
+		}
+
+		runs = append(runs, run)
+	}
+
+	if format == aoc.FormatJSON {
+		printJSONReport(os.Stdout, runs)
+	} else {
+		printReport(os.Stdout, runs)
+	}
+
+	return exitCode(runs)
+}
+
+// findRoot walks up from the working directory until it finds the go.mod of the repository.
+func findRoot() (string, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("could not get working directory: %w", err)
+	}
+
+	for {
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			return dir, nil
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", errors.New("no go.mod found")
+		}
+		dir = parent
+	}
+}
+
+// discoverDays finds every <YEAR>/day<DAY> package under root. A zero year or day matches all of them.
+func discoverDays(root string, year, day int) ([]Day, error) {
+	yearEntries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, fmt.Errorf("could not read root directory: %w", err)
+	}
+
+	var days []Day
+	for _, yearEntry := range yearEntries {
+		if !yearEntry.IsDir() || !yearDirRegex.MatchString(yearEntry.Name()) {
+			continue
+		}
+
+		y, _ := strconv.Atoi(yearEntry.Name())
+		if year != 0 && y != year {
+			continue
+		}
+
+		dayEntries, err := os.ReadDir(filepath.Join(root, yearEntry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("could not read year directory %s: %w", yearEntry.Name(), err)
+		}
+
+		for _, dayEntry := range dayEntries {
+			match := dayDirRegex.FindStringSubmatch(dayEntry.Name())
+			if !dayEntry.IsDir() || match == nil {
+				continue
+			}
+
+			d, _ := strconv.Atoi(match[1])
+			if day != 0 && d != day {
+				continue
+			}
+
+			dir := filepath.Join(root, yearEntry.Name(), dayEntry.Name())
+			if _, err := os.Stat(filepath.Join(dir, "main.go")); err != nil {
+				continue
+			}
+
+			days = append(days, Day{Year: y, Day: d, Dir: dir})
+		}
+	}
+
+	slices.SortFunc(days, func(a, b Day) int {
+		if a.Year != b.Year {
+			return a.Year - b.Year
+		}
+		return a.Day - b.Day
+	})
+
+	return days, nil
+}
+
+// runDay runs the day with `go run` and collects the parts from its "finished running part" and
+// "could not run part" log lines.
+func runDay(day Day, dayArgs []string) DayRun {
+	run := DayRun{Day: day}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("go", append([]string{"run", "."}, dayArgs...)...)
+	cmd.Dir = day.Dir
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	start := time.Now()
+	err := cmd.Run()
+	run.Duration = time.Since(start)
+
+	var lastLine string
+	debugErrs := map[string]string{}
+	scanner := bufio.NewScanner(&stderr)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) != "" {
+			lastLine = line
+		}
+
+		_, msg, attrs, ok := parseLogLine(line)
+		if !ok {
+			continue
+		}
+
+		switch msg {
+		case "finished running part":
+			run.Parts = append(run.Parts, PartResult{
+				Day:      day,
+				Part:     attrs["func"],
+				Input:    attrs["input"],
+				Result:   attrs["result"],
+				Duration: attrs["duration"],
+			})
+		case "could not run part":
+			run.Parts = append(run.Parts, PartResult{
+				Day:   day,
+				Part:  attrs["func"],
+				Input: attrs["input"],
+				Err:   attrs["err"],
+			})
+		case "part panicked":
+			run.Parts = append(run.Parts, PartResult{
+				Day:      day,
+				Part:     attrs["func"],
+				Input:    attrs["input"],
+				Err:      fmt.Sprintf("panic: %s (stack in %s)", attrs["panic"], attrs["debug"]),
+				Panicked: true,
+			})
+		case "part timed out", "part cancelled":
+			run.Parts = append(run.Parts, PartResult{
+				Day:      day,
+				Part:     attrs["func"],
+				Input:    attrs["input"],
+				Duration: attrs["duration"],
+				Err:      strings.TrimPrefix(msg, "part "),
+			})
+		case "could not write debug output":
+			// Logged before the result of the part
+			debugErrs[attrs["func"]+"\x00"+attrs["input"]] = attrs["err"]
+		case "could not benchmark part":
+			if part := run.part(attrs["func"], attrs["input"]); part != nil {
+				part.Err = "benchmark: " + attrs["err"]
+				part.Panicked = attrs["stack"] != ""
+			}
+		case "finished benchmarking part":
+			if part := run.part(attrs["func"], attrs["input"]); part != nil {
+				part.Bench = attrs
+			}
+		case "compared to baseline":
+			if part := run.part(attrs["func"], attrs["input"]); part != nil {
+				part.Baseline = attrs
+			}
+		}
+	}
+
+	if err != nil {
+		run.Err = fmt.Errorf("%w: %s", err, lastLine)
+	}
+	for i, part := range run.Parts {
+		run.Parts[i].DebugErr = debugErrs[part.Part+"\x00"+part.Input]
+	}
+
+	// Only days run with -format json write to stdout, anything that isn't a result is skipped
+	scanner = bufio.NewScanner(&stdout)
+	for scanner.Scan() {
+		var result aoc.Result
+		if json.Unmarshal(scanner.Bytes(), &result) == nil && result.Part != "" {
+			run.Results = append(run.Results, result)
+		}
+	}
+
+	return run
+}
+
+func (r *DayRun) part(name, input string) *PartResult {
+	for i := range r.Parts {
+		if r.Parts[i].Part == name && r.Parts[i].Input == input {
+			return &r.Parts[i]
+		}
+	}
+	return nil
+}
+
+// checkAnswers compares every successful part against the answers file of the day and
+// optionally saves the results that have no expected answer yet.
+func checkAnswers(run *DayRun, save bool) error {
+	answers, err := aoc.LoadAnswers(run.Day.Dir)
+	if err != nil {
+		return err
+	}
+
+	changed := false
+	for i, part := range run.Parts {
+		if part.Err != "" {
+			continue
+		}
+
+		// Variants like Part2-bruteforce share the answer of Part2
+		answerName := aoc.Part{Name: part.Part}.AnswerName()
+		run.Parts[i].Check = answers.Check(part.Input, answerName, part.Result)
+		if save && run.Parts[i].Check.Status == aoc.CheckNew {
+			answers.Set(part.Input, answerName, part.Result)
+			changed = true
+		}
+	}
+
+	if changed {
+		return answers.Save(run.Day.Dir)
+	}
+	return nil
+}
+
+// parseLogLine parses a line written by the default slog logger, e.g.
+// `2024/12/01 12:00:00 INFO finished running part func=Part1 duration=1ms result=42`
+func parseLogLine(line string) (level, msg string, attrs map[string]string, ok bool) {
+	var rest string
+	for _, l := range []string{"DEBUG", "INFO", "WARN", "ERROR"} {
+		if i := strings.Index(line, " "+l+" "); i >= 0 {
+			level = l
+			rest = line[i+len(l)+2:]
+			break
+		}
+	}
+	if level == "" {
+		return "", "", nil, false
+	}
+
+	attrs = map[string]string{}
+	var msgWords []string
+	for {
+		rest = strings.TrimLeft(rest, " ")
+		if rest == "" {
+			break
+		}
+
+		eq := strings.IndexByte(rest, '=')
+		space := strings.IndexByte(rest, ' ')
+		if eq < 0 || (space >= 0 && space < eq) {
+			if space < 0 {
+				msgWords = append(msgWords, rest)
+				break
+			}
+			msgWords = append(msgWords, rest[:space])
+			rest = rest[space:]
+			continue
+		}
+
+		key := rest[:eq]
+		rest = rest[eq+1:]
+
+		if quoted, err := strconv.QuotedPrefix(rest); err == nil {
+			attrs[key], _ = strconv.Unquote(quoted)
+			rest = rest[len(quoted):]
+			continue
+		}
+
+		space = strings.IndexByte(rest, ' ')
+		if space < 0 {
+			attrs[key] = rest
+			break
+		}
+		attrs[key] = rest[:space]
+		rest = rest[space:]
+	}
+
+	return level, strings.Join(msgWords, " "), attrs, true
+}
+
+// exitCode is the exit code of the command, which uses the same flags as the exit code of a day.
+func exitCode(runs []DayRun) int {
+	exitCode := 0
+	for _, run := range runs {
+		for _, part := range run.Parts {
+			if part.Panicked {
+				exitCode |= aoc.ExitPanicked
+			} else if part.Err != "" {
+				exitCode |= aoc.ExitFailed
+			} else if part.Check.Status == aoc.CheckFail {
+				exitCode |= aoc.ExitMismatch
+			}
+		}
+
+		if run.Err != nil && len(run.Parts) == 0 {
+			exitCode |= aoc.ExitFailed
+		}
+	}
+	return exitCode
+}
+
+// printReport writes one table for all runs
+func printReport(w io.Writer, runs []DayRun) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "YEAR\tDAY\tPART\tINPUT\tRESULT\tDURATION\tSTATUS")
+	for _, run := range runs {
+		for _, part := range run.Parts {
+			status := part.Check.String()
+			if part.Err != "" {
+				status = "error: " + part.Err
+			}
+			if part.DebugErr != "" {
+				status += " (warning: " + part.DebugErr + ")"
+			}
+			fmt.Fprintf(tw, "%d\t%d\t%s\t%s\t%s\t%s\t%s\n", part.Day.Year, part.Day.Day, part.Part, part.Input, part.Result, part.Duration, status)
+		}
+
+		// A day that exits non-zero without reporting any part could not run at all
+		if run.Err != nil && len(run.Parts) == 0 {
+			fmt.Fprintf(tw, "%d\t%d\t-\t\t\t%s\terror: %v\n", run.Day.Year, run.Day.Day, run.Duration.Round(time.Millisecond), run.Err)
+		}
+	}
+	tw.Flush()
+
+	printBenchReport(w, runs)
+}
+
+// printJSONReport writes the results of every day as JSON lines. A day that could not run at all
+// gets a single line with its error and no part.
+func printJSONReport(w io.Writer, runs []DayRun) {
+	results := aoc.NewResultWriter(w)
+	for _, run := range runs {
+		for _, result := range run.Results {
+			results.Write(result)
+		}
+
+		if run.Err != nil && len(run.Parts) == 0 {
+			results.Write(aoc.Result{Year: run.Day.Year, Day: run.Day.Day, Error: run.Err.Error()})
+		}
+	}
+}
+
+func printBenchReport(w io.Writer, runs []DayRun) {
+	var parts []PartResult
+	for _, run := range runs {
+		for _, part := range run.Parts {
+			if part.Bench != nil {
+				parts = append(parts, part)
+			}
+		}
+	}
+	if len(parts) == 0 {
+		return
+	}
+
+	fmt.Fprintln(w)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "YEAR\tDAY\tPART\tINPUT\tRUNS\tMIN\tMEDIAN\tP95\tALLOCS\tBYTES\tBASELINE\tSPEEDUP")
+	for _, part := range parts {
+		baseline, speedup := "-", "-"
+		if part.Baseline != nil {
+			baseline = part.Baseline["baseline"]
+			speedup = part.Baseline["speedup"] + " " + part.Baseline["status"]
+		}
+
+		fmt.Fprintf(tw, "%d\t%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			part.Day.Year, part.Day.Day, part.Part, part.Input,
+			part.Bench["runs"], part.Bench["min"], part.Bench["median"], part.Bench["p95"],
+			part.Bench["allocs"], part.Bench["bytes"], baseline, speedup,
+		)
+	}
+	tw.Flush()
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"maps"
+	"testing"
+)
+
+func TestParseLogLine(t *testing.T) {
+	tests := []struct {
+		name  string
+		line  string
+		level string
+		msg   string
+		attrs map[string]string
+		ok    bool
+	}{
+		{
+			name:  "result",
+			line:  "2024/12/01 12:00:00 INFO finished running part func=Part1 input=input.txt duration=1.2ms result=42",
+			level: "INFO",
+			msg:   "finished running part",
+			attrs: map[string]string{"func": "Part1", "input": "input.txt", "duration": "1.2ms", "result": "42"},
+			ok:    true,
+		},
+		{
+			name:  "quoted values",
+			line:  `2024/12/01 12:00:00 ERROR could not run part func=Part2 input="my input.txt" err="could not parse \"x=1\": invalid syntax"`,
+			level: "ERROR",
+			msg:   "could not run part",
+			attrs: map[string]string{"func": "Part2", "input": "my input.txt", "err": `could not parse "x=1": invalid syntax`},
+			ok:    true,
+		},
+		{
+			name:  "empty value",
+			line:  "2024/12/01 12:00:00 WARN part timed out func=Part1 result= duration=5s",
+			level: "WARN",
+			msg:   "part timed out",
+			attrs: map[string]string{"func": "Part1", "result": "", "duration": "5s"},
+			ok:    true,
+		},
+		{
+			name:  "no attributes",
+			line:  "2024/12/01 12:00:00 DEBUG starting",
+			level: "DEBUG",
+			msg:   "starting",
+			attrs: map[string]string{},
+			ok:    true,
+		},
+		{name: "plain output", line: "Part1: 42", ok: false},
+		{name: "empty line", line: "", ok: false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			level, msg, attrs, ok := parseLogLine(test.line)
+			if ok != test.ok {
+				t.Fatalf("expected ok to be %v, got %v", test.ok, ok)
+			}
+			if level != test.level || msg != test.msg {
+				t.Errorf("expected %q %q, got %q %q", test.level, test.msg, level, msg)
+			}
+			if !maps.Equal(attrs, test.attrs) {
+				t.Errorf("expected %q, got %q", test.attrs, attrs)
+			}
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package frame
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Style colors a run of bytes of Data
+type Style struct {
+	Offset int `json:"offset"`
+	Length int `json:"length"`
+	// Color and Background are an ANSI color number from 0 to 15 or a hex color like #ff0000
+	Color      string `json:"color"`
+	Background string `json:"background,omitempty"`
+	// Reverse swaps the color and the background
+	Reverse bool `json:"reverse,omitempty"`
+}
+
+// sgr is what the select graphic rendition sequences so far set
+type sgr struct {
+	color, background string
+	reverse           bool
+}
+
+// ParseAnsi removes the ANSI escape sequences from s and returns the colors they set as styles of
+// the plain text. The 16 basic colors, 256 colors, true colors and reverse are kept, other
+// sequences are dropped.
+func ParseAnsi(s string) (string, []Style) {
+	if !strings.Contains(s, "\033[") {
+		return s, nil
+	}
+
+	var plain strings.Builder
+	var styles []Style
+	var state sgr
+	start := 0
+	endRun := func() {
+		if state != (sgr{}) && plain.Len() > start {
+			styles = append(styles, Style{
+				Offset:     start,
+				Length:     plain.Len() - start,
+				Color:      state.color,
+				Background: state.background,
+				Reverse:    state.reverse,
+			})
+		}
+		start = plain.Len()
+	}
+
+	for {
+		i := strings.Index(s, "\033[")
+		if i < 0 {
+			plain.WriteString(s)
+			break
+		}
+		plain.WriteString(s[:i])
+		s = s[i+2:]
+
+		// The sequence ends with its first letter
+		end := strings.IndexFunc(s, func(r rune) bool { return r >= '@' && r <= '~' })
+		if end < 0 {
+			break
+		}
+		params, command := s[:end], s[end]
+		s = s[end+1:]
+		if command != 'm' {
+			continue
+		}
+
+		endRun()
+		state = state.apply(params)
+	}
+	endRun()
+
+	return plain.String(), styles
+}
+
+// AnsiColor returns the color an escape sequence like "\033[0;91m" sets, "" when it sets none
+func AnsiColor(code string) string {
+	params, ok := strings.CutPrefix(code, "\033[")
+	if !ok || !strings.HasSuffix(params, "m") {
+		return ""
+	}
+	return sgr{}.apply(strings.TrimSuffix(params, "m")).color
+}
+
+// apply returns the state after the parameters of a select graphic rendition
+func (s sgr) apply(params string) sgr {
+	if params == "" {
+		return sgr{}
+	}
+
+	codes := strings.Split(params, ";")
+	for i := 0; i < len(codes); i++ {
+		n, err := strconv.Atoi(codes[i])
+		if err != nil {
+			continue
+		}
+
+		switch {
+		case n == 0:
+			s = sgr{}
+		case n == 7:
+			s.reverse = true
+		case n == 27:
+			s.reverse = false
+		case n == 39:
+			s.color = ""
+		case n == 49:
+			s.background = ""
+		case n >= 30 && n <= 37:
+			s.color = strconv.Itoa(n - 30)
+		case n >= 90 && n <= 97:
+			s.color = strconv.Itoa(n - 90 + 8)
+		case n >= 40 && n <= 47:
+			s.background = strconv.Itoa(n - 40)
+		case n >= 100 && n <= 107:
+			s.background = strconv.Itoa(n - 100 + 8)
+		case (n == 38 || n == 48) && i+1 < len(codes):
+			var color string
+			color, i = extendedColor(codes, i+1)
+			if n == 38 {
+				s.color = color
+			} else {
+				s.background = color
+			}
+		}
+	}
+	return s
+}
+
+// extendedColor reads a 256 color (5;n) or true color (2;r;g;b) starting at i and returns the
+// index of its last code
+func extendedColor(codes []string, i int) (string, int) {
+	switch {
+	case codes[i] == "2" && i+3 < len(codes):
+		r, _ := strconv.Atoi(codes[i+1])
+		g, _ := strconv.Atoi(codes[i+2])
+		b, _ := strconv.Atoi(codes[i+3])
+		return fmt.Sprintf("#%02x%02x%02x", r, g, b), i + 3
+	case codes[i] == "5" && i+1 < len(codes):
+		n, _ := strconv.Atoi(codes[i+1])
+		return color256(n), i + 1
+	}
+	return "", i
+}
+
+// color256 is the color of the xterm 256 color palette, the first 16 are the basic colors
+func color256(n int) string {
+	switch {
+	case n < 16:
+		return strconv.Itoa(max(n, 0))
+	case n < 232:
+		// 6×6×6 color cube
+		n -= 16
+		levels := []int{0, 95, 135, 175, 215, 255}
+		return fmt.Sprintf("#%02x%02x%02x", levels[n/36], levels[n/6%6], levels[n%6])
+	case n < 256:
+		gray := 8 + (n-232)*10
+		return fmt.Sprintf("#%02x%02x%02x", gray, gray, gray)
+	}
+	return ""
+}
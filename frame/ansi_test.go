@@ -0,0 +1,56 @@
+package frame
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseAnsi(t *testing.T) {
+	tests := []struct {
+		name   string
+		s      string
+		plain  string
+		styles []Style
+	}{
+		{name: "plain", s: "#..\n.^.", plain: "#..\n.^."},
+		{name: "basic colors", s: "a\033[0;91mbc\033[mdd\033[0;32me", plain: "abcdde", styles: []Style{{Offset: 1, Length: 2, Color: "9"}, {Offset: 5, Length: 1, Color: "2"}}},
+		{name: "background", s: "\033[41ma\033[49mb", plain: "ab", styles: []Style{{Offset: 0, Length: 1, Background: "1"}}},
+		{name: "reverse", s: "\033[7ma\033[27mb", plain: "ab", styles: []Style{{Offset: 0, Length: 1, Reverse: true}}},
+		{name: "256 colors", s: "\033[38;5;9ma\033[38;5;196mb\033[48;5;232mc", plain: "abc", styles: []Style{
+			{Offset: 0, Length: 1, Color: "9"},
+			{Offset: 1, Length: 1, Color: "#ff0000"},
+			{Offset: 2, Length: 1, Color: "#ff0000", Background: "#080808"},
+		}},
+		{name: "true color", s: "\033[38;2;1;2;3;1ma", plain: "a", styles: []Style{{Offset: 0, Length: 1, Color: "#010203"}}},
+		{name: "other sequences", s: "\033[2J\033[Ha\033[1mb", plain: "ab"},
+		{name: "cut off sequence", s: "a\033[38;5", plain: "a"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			plain, styles := ParseAnsi(test.s)
+			if plain != test.plain {
+				t.Errorf("expected %q, got %q", test.plain, plain)
+			}
+			if !slices.Equal(styles, test.styles) {
+				t.Errorf("expected %+v, got %+v", test.styles, styles)
+			}
+		})
+	}
+}
+
+func TestAnsiColor(t *testing.T) {
+	tests := []struct {
+		code string
+		want string
+	}{
+		{code: "\033[0;91m", want: "9"},
+		{code: "\033[0;30m", want: "0"},
+		{code: "\033[m", want: ""},
+		{code: "red", want: ""},
+	}
+	for _, test := range tests {
+		if got := AnsiColor(test.code); got != test.want {
+			t.Errorf("%q: expected %q, got %q", test.code, test.want, got)
+		}
+	}
+}
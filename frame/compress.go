@@ -0,0 +1,78 @@
+package frame
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	CompressNone = ""
+	CompressGzip = "gzip"
+	CompressZstd = "zstd"
+)
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// Compressor is a compressing writer that can flush what it has so far
+type Compressor interface {
+	io.WriteCloser
+	Flush() error
+}
+
+// CompressExt is the extension added to a debug file written with the compression
+func CompressExt(compression string) string {
+	switch compression {
+	case CompressGzip:
+		return ".gz"
+	case CompressZstd:
+		return ".zst"
+	}
+	return ""
+}
+
+// NewCompressor compresses everything written to w
+func NewCompressor(w io.Writer, compression string) (Compressor, error) {
+	switch compression {
+	case CompressGzip:
+		return gzip.NewWriter(w), nil
+	case CompressZstd:
+		encoder, err := zstd.NewWriter(w)
+		if err != nil {
+			return nil, fmt.Errorf("could not create zstd encoder: %w", err)
+		}
+		return encoder, nil
+	}
+	return nil, fmt.Errorf("unknown compression %#v", compression)
+}
+
+// NewReader decompresses r when it starts with a gzip or zstd header and otherwise reads it as is.
+// A file that is still written ends in the middle of a block, reading it then returns
+// io.ErrUnexpectedEOF after everything that could be decompressed.
+func NewReader(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	header, _ := br.Peek(len(zstdMagic))
+
+	switch {
+	case bytes.HasPrefix(header, gzipMagic):
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("could not read gzip header: %w", err)
+		}
+		return gz, nil
+	case bytes.HasPrefix(header, zstdMagic):
+		decoder, err := zstd.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("could not create zstd decoder: %w", err)
+		}
+		return decoder.IOReadCloser(), nil
+	}
+	return br, nil
+}
@@ -0,0 +1,87 @@
+// Package frame is the format of the steps a part writes for the visualizer.
+//
+// Every frame is a JSON text sequence record (RFC 7464): a record separator, the frame as one line
+// of JSON and a newline. Anything else in the debug output is ignored, so a part can still write
+// free text around its frames. The marker format written by older days is read as well.
+package frame
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Version is written into every frame, readers skip frames of a newer version
+const Version = 1
+
+// RecordSeparator starts every frame
+const RecordSeparator = '\x1e'
+
+// The markers of the old format, a step is everything between two step markers with an optional
+// meta block before the data marker
+const (
+	LegacyStep = "==========STEP==========\n"
+	LegacyData = "==========DATA==========\n"
+	LegacyEnd  = "==========END==========\n"
+)
+
+// Frame is one step of a part
+type Frame struct {
+	Version int    `json:"v"`
+	Step    int    `json:"step"`
+	Meta    string `json:"meta,omitempty"`
+	Data    string `json:"data"`
+	// Width and Height are the size of a grid in Data, when it has one
+	Width       int          `json:"width,omitempty"`
+	Height      int          `json:"height,omitempty"`
+	Annotations []Annotation `json:"annotations,omitempty"`
+	// Styles are the colors the part gave Data, taken from its ANSI codes
+	Styles []Style `json:"styles,omitempty"`
+	// Layers are more grids of the same size as Data, shown next to it or over it
+	Layers []Layer `json:"layers,omitempty"`
+}
+
+// Layer is a named grid, its spaces are transparent when it is drawn over Data
+type Layer struct {
+	Name string `json:"name"`
+	Data string `json:"data"`
+}
+
+// Annotation marks a cell of the grid in Data
+type Annotation struct {
+	X     int    `json:"x"`
+	Y     int    `json:"y"`
+	Label string `json:"label,omitempty"`
+}
+
+// Encode returns the record of the frame
+func Encode(f Frame) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte(RecordSeparator)
+
+	// Keep meta like "->" readable in the debug file
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	err := encoder.Encode(f)
+	if err != nil {
+		return nil, fmt.Errorf("could not encode frame: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Write writes the record of the frame
+func Write(w io.Writer, f Frame) error {
+	record, err := Encode(f)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(record)
+	if err != nil {
+		return fmt.Errorf("could not write frame: %w", err)
+	}
+
+	return nil
+}
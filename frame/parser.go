@@ -0,0 +1,145 @@
+package frame
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+type format int
+
+const (
+	formatUnknown format = iota
+	formatRecords
+	formatLegacy
+)
+
+// Parser reads frames from debug output as it arrives, so a file and a live stream are read the
+// same way. The format is picked by whatever comes first, a record or a legacy step marker.
+type Parser struct {
+	pending []byte
+	format  format
+	ended   bool
+	steps   int
+}
+
+// Feed adds debug output and returns the frames it completed
+func (p *Parser) Feed(data []byte) []Frame {
+	if p.ended {
+		return nil
+	}
+	// A \r\n split over two reads is joined once the \n arrives since the \r stays pending
+	p.pending = bytes.ReplaceAll(append(p.pending, data...), []byte("\r\n"), []byte("\n"))
+
+	if p.format == formatUnknown {
+		record := bytes.IndexByte(p.pending, RecordSeparator)
+		legacy := bytes.Index(p.pending, []byte(LegacyStep))
+		switch {
+		case record >= 0 && (legacy < 0 || record < legacy):
+			p.format = formatRecords
+		case legacy >= 0:
+			p.format = formatLegacy
+		default:
+			// Keep enough to find a marker split over two reads
+			p.pending = p.pending[max(len(p.pending)-len(LegacyStep), 0):]
+			return nil
+		}
+	}
+
+	if p.format == formatRecords {
+		return p.feedRecords()
+	}
+	return p.feedLegacy()
+}
+
+// Close returns the last frame, which may not be complete without the end of the output
+func (p *Parser) Close() []Frame {
+	if p.ended {
+		return nil
+	}
+	p.ended = true
+
+	switch p.format {
+	case formatRecords:
+		p.pending = append(p.pending, '\n')
+		return p.feedRecords()
+	case formatLegacy:
+		if !bytes.HasPrefix(p.pending, []byte(LegacyStep)) {
+			return nil
+		}
+		return []Frame{p.legacyFrame(string(p.pending[len(LegacyStep):]))}
+	}
+	return nil
+}
+
+func (p *Parser) feedRecords() []Frame {
+	var frames []Frame
+	for {
+		start := bytes.IndexByte(p.pending, RecordSeparator)
+		if start < 0 {
+			// Free text between frames
+			p.pending = p.pending[:0]
+			return frames
+		}
+
+		end := bytes.IndexByte(p.pending[start:], '\n')
+		if end < 0 {
+			p.pending = p.pending[start:]
+			return frames
+		}
+
+		var f Frame
+		err := json.Unmarshal(p.pending[start+1:start+end], &f)
+		// A broken or newer frame is skipped instead of ending the whole run
+		if err == nil && f.Version <= Version {
+			frames = append(frames, f)
+		}
+		p.pending = p.pending[start+end+1:]
+	}
+}
+
+func (p *Parser) feedLegacy() []Frame {
+	var frames []Frame
+	for {
+		if !bytes.HasPrefix(p.pending, []byte(LegacyStep)) {
+			start := bytes.Index(p.pending, []byte(LegacyStep))
+			if start < 0 {
+				return frames
+			}
+			p.pending = p.pending[start:]
+		}
+
+		rest := p.pending[len(LegacyStep):]
+		next := bytes.Index(rest, []byte(LegacyStep))
+		if next < 0 {
+			next = len(rest)
+		}
+
+		if end := bytes.Index(rest[:next], []byte(LegacyEnd)); end >= 0 {
+			frames = append(frames, p.legacyFrame(string(rest[:end])))
+			p.pending = nil
+			p.ended = true
+			return frames
+		}
+
+		if next == len(rest) {
+			return frames
+		}
+
+		frames = append(frames, p.legacyFrame(string(rest[:next])))
+		p.pending = rest[next:]
+	}
+}
+
+func (p *Parser) legacyFrame(s string) Frame {
+	p.steps++
+
+	s = strings.TrimSuffix(s, "\n")
+	f := Frame{Step: p.steps, Data: s}
+	if strings.Contains(s, LegacyData) {
+		parts := strings.Split(s, LegacyData)
+		f.Meta = strings.TrimSuffix(parts[0], "\n")
+		f.Data = strings.TrimSuffix(parts[1], "\n")
+	}
+	return f
+}
@@ -0,0 +1,75 @@
+package frame
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// step is the part of a frame the parser tests compare
+type step struct {
+	step       int
+	meta, data string
+}
+
+func record(step int, data string) string {
+	return fmt.Sprintf("\x1e{\"v\":1,\"step\":%d,\"data\":%q}\n", step, data)
+}
+
+func TestParser(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   []step
+	}{
+		{name: "records", output: record(1, "#.") + record(2, ".#"), want: []step{{1, "", "#."}, {2, "", ".#"}}},
+		{name: "records with meta", output: "\x1e{\"v\":1,\"step\":4,\"meta\":\"m\",\"data\":\"#\"}\n", want: []step{{4, "m", "#"}}},
+		{name: "free text around records", output: "log\n" + record(1, "#") + "more log\n" + record(2, ".") + "end\n", want: []step{{1, "", "#"}, {2, "", "."}}},
+		{name: "last record without newline", output: record(1, "#") + strings.TrimSuffix(record(2, "."), "\n"), want: []step{{1, "", "#"}, {2, "", "."}}},
+		{name: "broken and newer records", output: "\x1e{\"v\":1,\"st\n" + "\x1e{\"v\":2,\"step\":1,\"data\":\"#\"}\n" + record(2, "."), want: []step{{2, "", "."}}},
+		{name: "records with \\r\\n", output: "log\r\n\x1e{\"v\":1,\"step\":1,\"data\":\"#\"}\r\n", want: []step{{1, "", "#"}}},
+		{name: "record before legacy marker", output: record(1, "#") + LegacyStep + "a\n", want: []step{{1, "", "#"}}},
+		{name: "legacy", output: "log\n" + LegacyStep + "a\n" + LegacyStep + "m\n" + LegacyData + "b\n" + LegacyEnd, want: []step{{1, "", "a"}, {2, "m", "b"}}},
+		{name: "legacy without end", output: LegacyStep + "a\n" + LegacyStep + "b\n", want: []step{{1, "", "a"}, {2, "", "b"}}},
+		{name: "legacy output after end", output: LegacyStep + "a\n" + LegacyEnd + LegacyStep + "b\n", want: []step{{1, "", "a"}}},
+		{name: "legacy with \\r\\n", output: "==========STEP==========\r\nm\r\n==========DATA==========\r\n#.\r\n==========END==========\r\n", want: []step{{1, "m", "#."}}},
+		{name: "legacy marker before record", output: LegacyStep + "a\n" + record(1, "#"), want: []step{{1, "", "a\n" + strings.TrimSuffix(record(1, "#"), "\n")}}},
+		{name: "no frames", output: "just some text\n", want: []step{}},
+	}
+	for _, test := range tests {
+		// Every output is also fed a byte at a time to split the markers and records over reads
+		for _, chunk := range []int{len(test.output), 1} {
+			t.Run(fmt.Sprintf("%s/%d", test.name, chunk), func(t *testing.T) {
+				var parser Parser
+				var frames []Frame
+				for i := 0; i < len(test.output); i += chunk {
+					frames = append(frames, parser.Feed([]byte(test.output[i:min(i+chunk, len(test.output))]))...)
+				}
+				frames = append(frames, parser.Close()...)
+
+				got := []step{}
+				for _, f := range frames {
+					got = append(got, step{f.Step, f.Meta, f.Data})
+				}
+				if !slices.Equal(got, test.want) {
+					t.Errorf("expected %q, got %q", test.want, got)
+				}
+			})
+		}
+	}
+}
+
+func TestParserClose(t *testing.T) {
+	var parser Parser
+	parser.Feed([]byte(LegacyStep + "a\n"))
+	if frames := parser.Close(); len(frames) != 1 || frames[0].Data != "a" {
+		t.Errorf("expected the last frame on Close, got %+v", frames)
+	}
+	if frames := parser.Close(); frames != nil {
+		t.Errorf("expected nothing on a second Close, got %+v", frames)
+	}
+	if frames := parser.Feed([]byte(record(2, "#"))); frames != nil {
+		t.Errorf("expected nothing after Close, got %+v", frames)
+	}
+}
@@ -1,3 +1,5 @@
 module main
 
 go 1.23.2
+
+require github.com/klauspost/compress v1.18.0
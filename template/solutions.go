@@ -1,24 +1,13 @@
 package main
 
 import (
-	"flag"
+	"context"
 	"strings"
-)
-
-func parseArgs() Args {
-	var args Args
-	flag.StringVar(&args.Input, "input", "input.txt", "")
-	flag.StringVar(&args.PartFilter, "part", "", "")
-	flag.BoolVar(&args.Verbose, "v", false, "verbose debug")
-	flag.Parse()
-
-	// args.Input = "input-example-1.txt"
-	// args.Part = "1"
 
-	return args
-}
+	"main/aoc"
+)
 
-func Part1(input string, debug *Debugger) (any, error) {
+func Part1(ctx context.Context, input string, debug *aoc.Debugger) (any, error) {
 	result := 0
 
 	for _, line := range strings.Split(input, "\n") {
@@ -28,7 +17,7 @@ func Part1(input string, debug *Debugger) (any, error) {
 	return result, nil
 }
 
-func Part2(input string, debug *Debugger) (any, error) {
+func Part2(ctx context.Context, input string, debug *aoc.Debugger) (any, error) {
 	result := 0
 
 	for _, line := range strings.Split(input, "\n") {
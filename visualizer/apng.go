@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/png"
+	"io"
+	"time"
+)
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+type pngChunk struct {
+	kind string
+	data []byte
+}
+
+// encodeAPNG writes the images as an animated PNG that loops forever. The images are encoded by
+// image/png and their chunks are rewritten into the frames of the animation, so they all need the
+// size and palette of the first one.
+func encodeAPNG(w io.Writer, images []*image.Paletted, delay time.Duration) error {
+	if len(images) == 0 {
+		return errors.New("no images")
+	}
+
+	var chunks []pngChunk
+	writeChunk := func(kind string, data []byte) {
+		chunks = append(chunks, pngChunk{kind: kind, data: data})
+	}
+
+	sequence := uint32(0)
+	bounds := images[0].Bounds()
+	delayMs := uint16(min(delay.Milliseconds(), 0xffff))
+	for i, img := range images {
+		var buf bytes.Buffer
+		err := png.Encode(&buf, img)
+		if err != nil {
+			return err
+		}
+
+		frameChunks, err := readPNGChunks(buf.Bytes())
+		if err != nil {
+			return err
+		}
+
+		if i == 0 {
+			for _, chunk := range frameChunks {
+				if chunk.kind == "IHDR" {
+					writeChunk("IHDR", chunk.data)
+					writeChunk("acTL", binary.BigEndian.AppendUint32(binary.BigEndian.AppendUint32(nil, uint32(len(images))), 0))
+				}
+				if chunk.kind == "PLTE" || chunk.kind == "tRNS" {
+					writeChunk(chunk.kind, chunk.data)
+				}
+			}
+		}
+
+		// Frame control: sequence, size, offset, delay as a fraction and no dispose or blend
+		fctl := binary.BigEndian.AppendUint32(nil, sequence)
+		fctl = binary.BigEndian.AppendUint32(fctl, uint32(bounds.Dx()))
+		fctl = binary.BigEndian.AppendUint32(fctl, uint32(bounds.Dy()))
+		fctl = binary.BigEndian.AppendUint32(fctl, 0)
+		fctl = binary.BigEndian.AppendUint32(fctl, 0)
+		fctl = binary.BigEndian.AppendUint16(fctl, delayMs)
+		fctl = binary.BigEndian.AppendUint16(fctl, 1000)
+		fctl = append(fctl, 0, 0)
+		writeChunk("fcTL", fctl)
+		sequence++
+
+		for _, chunk := range frameChunks {
+			if chunk.kind != "IDAT" {
+				continue
+			}
+
+			if i == 0 {
+				writeChunk("IDAT", chunk.data)
+				continue
+			}
+			writeChunk("fdAT", append(binary.BigEndian.AppendUint32(nil, sequence), chunk.data...))
+			sequence++
+		}
+	}
+	writeChunk("IEND", nil)
+
+	_, err := w.Write(pngSignature)
+	if err != nil {
+		return err
+	}
+	for _, chunk := range chunks {
+		err := writePNGChunk(w, chunk)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readPNGChunks(data []byte) ([]pngChunk, error) {
+	if !bytes.HasPrefix(data, pngSignature) {
+		return nil, errors.New("missing PNG signature")
+	}
+	data = data[len(pngSignature):]
+
+	var chunks []pngChunk
+	for len(data) >= 12 {
+		length := int(binary.BigEndian.Uint32(data))
+		if len(data) < 12+length {
+			return nil, errors.New("truncated PNG chunk")
+		}
+		chunks = append(chunks, pngChunk{kind: string(data[4:8]), data: data[8 : 8+length]})
+		data = data[12+length:]
+	}
+	return chunks, nil
+}
+
+func writePNGChunk(w io.Writer, chunk pngChunk) error {
+	buf := binary.BigEndian.AppendUint32(nil, uint32(len(chunk.data)))
+	buf = append(buf, chunk.kind...)
+	buf = append(buf, chunk.data...)
+	buf = binary.BigEndian.AppendUint32(buf, crc32.ChecksumIEEE(buf[4:]))
+
+	_, err := w.Write(buf)
+	return err
+}
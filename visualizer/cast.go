@@ -0,0 +1,173 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"main/frame"
+)
+
+// Size of a character in an exported SVG in pixels
+const (
+	svgFontSize   = 14
+	svgCellWidth  = 8.4
+	svgCellHeight = 17
+)
+
+// ansiPalette are the 16 basic terminal colors as xterm shows them
+var ansiPalette = []string{
+	"#000000", "#cd0000", "#00cd00", "#cdcd00", "#0000ee", "#cd00cd", "#00cdcd", "#e5e5e5",
+	"#7f7f7f", "#ff0000", "#00ff00", "#ffff00", "#5c5cff", "#ff00ff", "#00ffff", "#ffffff",
+}
+
+const (
+	svgBackground = "#1e1e1e"
+	svgForeground = "#d0d0d0"
+)
+
+// encodeCast writes the views as an asciinema v2 recording, every view replaces the screen
+func encodeCast(w io.Writer, views []string, delay time.Duration) error {
+	width, height := viewSize(views)
+	encoder := json.NewEncoder(w)
+	err := encoder.Encode(map[string]any{
+		"version":   2,
+		"width":     width,
+		"height":    height,
+		"timestamp": time.Now().Unix(),
+		"env":       map[string]string{"TERM": "xterm-256color"},
+	})
+	if err != nil {
+		return err
+	}
+
+	for i, view := range views {
+		// Move home and clear, the terminal needs \r\n to start the next line at the left
+		output := "\033[H\033[2J" + strings.ReplaceAll(view, "\n", "\r\n")
+		seconds := (time.Duration(i) * delay).Seconds()
+		err := encoder.Encode([]any{seconds, "o", output})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// encodeSVG writes the views as a self-contained SVG that shows one view after the other with a
+// CSS animation and loops forever
+func encodeSVG(w io.Writer, views []string, delay time.Duration) error {
+	width, height := viewSize(views)
+	total := time.Duration(len(views)) * delay
+
+	var svg strings.Builder
+	fmt.Fprintf(&svg, `<svg xmlns="http://www.w3.org/2000/svg" width="%.0f" height="%d" font-family="monospace" font-size="%d">`+"\n",
+		float64(width)*svgCellWidth, height*svgCellHeight, svgFontSize)
+	fmt.Fprintf(&svg, "<style>g.view{visibility:hidden;animation:view %.3fs step-end infinite}"+
+		"@keyframes view{0%%{visibility:visible}%.4f%%{visibility:hidden}}</style>\n",
+		total.Seconds(), 100/float64(len(views)))
+	fmt.Fprintf(&svg, `<rect width="100%%" height="100%%" fill="%s"/>`+"\n", svgBackground)
+
+	for i, view := range views {
+		fmt.Fprintf(&svg, `<g class="view" style="animation-delay:%.3fs">`+"\n", (time.Duration(i) * delay).Seconds())
+		for y, line := range strings.Split(view, "\n") {
+			writeSVGLine(&svg, line, y)
+		}
+		svg.WriteString("</g>\n")
+	}
+	svg.WriteString("</svg>\n")
+
+	_, err := io.WriteString(w, svg.String())
+	return err
+}
+
+// writeSVGLine writes the backgrounds and the text of a line of a view, every run of the same
+// colors is placed at its column so the text lines up with the backgrounds
+func writeSVGLine(svg *strings.Builder, line string, y int) {
+	var text strings.Builder
+	top := y * svgCellHeight
+	baseline := top + svgCellHeight - 4
+	for _, span := range ansiSpans(line) {
+		x := float64(span.column) * svgCellWidth
+		if span.bg != "" {
+			fmt.Fprintf(svg, `<rect x="%.1f" y="%d" width="%.1f" height="%d" fill="%s"/>`+"\n",
+				x, top, float64(len([]rune(span.text)))*svgCellWidth, svgCellHeight, span.bg)
+		}
+		if strings.TrimSpace(span.text) != "" {
+			fmt.Fprintf(&text, `<tspan x="%.1f" fill="%s">%s</tspan>`, x, span.fg, html.EscapeString(span.text))
+		}
+	}
+	if text.Len() > 0 {
+		fmt.Fprintf(svg, `<text y="%d" xml:space="preserve">%s</text>`+"\n", baseline, text.String())
+	}
+}
+
+// viewSize is the size of the largest view in characters
+func viewSize(views []string) (int, int) {
+	width, height := 0, 0
+	for _, view := range views {
+		width = max(width, lipgloss.Width(view))
+		height = max(height, lipgloss.Height(view))
+	}
+	return width, height
+}
+
+type ansiSpan struct {
+	text   string
+	column int
+	fg, bg string
+}
+
+// ansiSpans splits a line of terminal output into runs of text with the same colors
+func ansiSpans(line string) []ansiSpan {
+	plain, styles := frame.ParseAnsi(line)
+
+	var spans []ansiSpan
+	offset := 0
+	addSpan := func(end int, style frame.Style) {
+		if end <= offset {
+			return
+		}
+
+		span := ansiSpan{
+			text:   plain[offset:end],
+			column: utf8.RuneCountInString(plain[:offset]),
+			fg:     paletteColor(style.Color, svgForeground),
+			bg:     paletteColor(style.Background, ""),
+		}
+		if style.Reverse {
+			span.fg, span.bg = paletteColor(style.Background, svgBackground), span.fg
+		}
+		spans = append(spans, span)
+		offset = end
+	}
+	for _, style := range styles {
+		addSpan(style.Offset, frame.Style{})
+		addSpan(style.Offset+style.Length, style)
+	}
+	addSpan(len(plain), frame.Style{})
+
+	return spans
+}
+
+// paletteColor is the hex color of a color of a frame.Style, an ANSI color number is looked up in
+// the palette. An empty color is the default.
+func paletteColor(color, defaultColor string) string {
+	if color == "" {
+		return defaultColor
+	}
+	if n, err := strconv.Atoi(color); err == nil {
+		if n < 0 || n >= len(ansiPalette) {
+			return defaultColor
+		}
+		return ansiPalette[n]
+	}
+	return color
+}
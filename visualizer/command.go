@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// timelineWidth is how many columns the match timeline in the header has at most
+const timelineWidth = 60
+
+// updateCommand edits the command line while it is open. Enter runs it and esc closes it.
+func (m Model) updateCommand(msg tea.KeyMsg) Model {
+	switch msg.Type {
+	case tea.KeyEnter:
+		m = m.runCommand(m.Command)
+		m.Command = ""
+	case tea.KeyEsc, tea.KeyCtrlC:
+		m.Command = ""
+	case tea.KeyBackspace:
+		_, size := utf8.DecodeLastRuneInString(m.Command)
+		m.Command = m.Command[:len(m.Command)-size]
+	case tea.KeyRunes, tea.KeySpace:
+		m.Command += string(msg.Runes)
+	}
+	return m
+}
+
+// runCommand runs ":N" to go to step N of the part and "/regex" to search the meta and data of
+// every step
+func (m Model) runCommand(command string) Model {
+	m.Message = ""
+
+	if pattern, ok := strings.CutPrefix(command, "/"); ok {
+		if pattern == "" {
+			m.Search, m.Matches = nil, nil
+			return m
+		}
+
+		search, err := regexp.Compile(pattern)
+		if err != nil {
+			m.Message = fmt.Sprintf("invalid search: %v", err)
+			return m
+		}
+		m.Search, m.Matches = search, nil
+		m = m.matchSteps(0)
+		if len(m.Matches) == 0 {
+			m.Message = "no matches for " + pattern
+			return m
+		}
+
+		if !slices.Contains(m.Matches, m.CurrentStep) {
+			m = m.nextMatch()
+		}
+		return m
+	}
+
+	arg := strings.TrimSpace(strings.TrimPrefix(command, ":"))
+	step, err := strconv.Atoi(arg)
+	if err != nil {
+		m.Message = fmt.Sprintf("unknown command %#v, use :STEP or /REGEX", command)
+		return m
+	}
+	if len(m.Steps) > 0 {
+		m.CurrentStep = m.stepIndex(step)
+		m.Paused = true
+	}
+	return m
+}
+
+// solverStep is the step number the part gave the step at the index. It differs from the index
+// once the Debugger dropped steps with -debug-every or -debug-keep-last.
+func (m Model) solverStep(index int) int {
+	if step := m.Steps[index-1].Step; step > 0 {
+		return step
+	}
+	return index
+}
+
+// stepIndex is the index of the step with the step number of the part, or of the next step that
+// was kept after it
+func (m Model) stepIndex(step int) int {
+	i := sort.Search(len(m.Steps), func(i int) bool { return m.solverStep(i+1) >= step })
+	return min(i+1, len(m.Steps))
+}
+
+// matchSteps adds the steps from the index on that match the search to the matches
+func (m Model) matchSteps(from int) Model {
+	if m.Search == nil {
+		return m
+	}
+
+	for i, step := range m.Steps[from:] {
+		if m.Search.MatchString(step.Meta) || m.Search.MatchString(step.Data) {
+			m.Matches = append(m.Matches, from+i+1)
+		}
+	}
+	return m
+}
+
+// nextMatch goes to the first match after the current step, wrapping around to the first match
+func (m Model) nextMatch() Model {
+	if len(m.Matches) == 0 {
+		return m
+	}
+
+	i, found := slices.BinarySearch(m.Matches, m.CurrentStep)
+	if found {
+		i++
+	}
+	m.CurrentStep = m.Matches[i%len(m.Matches)]
+	m.Paused = true
+	return m
+}
+
+// previousMatch goes to the last match before the current step, wrapping around to the last match
+func (m Model) previousMatch() Model {
+	if len(m.Matches) == 0 {
+		return m
+	}
+
+	i, _ := slices.BinarySearch(m.Matches, m.CurrentStep)
+	m.CurrentStep = m.Matches[(i-1+len(m.Matches))%len(m.Matches)]
+	m.Paused = true
+	return m
+}
+
+// searchHeader shows which match the current step is and a timeline with the matches marked
+func (m Model) searchHeader() string {
+	if m.Search == nil || len(m.Steps) == 0 {
+		return ""
+	}
+
+	status := fmt.Sprintf("/%s: %d matches", m.Search, len(m.Matches))
+	if i, found := slices.BinarySearch(m.Matches, m.CurrentStep); found {
+		status = fmt.Sprintf("/%s: match %d/%d", m.Search, i+1, len(m.Matches))
+	}
+
+	// Every column of the timeline covers the same number of steps
+	width := min(len(m.Steps), timelineWidth)
+	timeline := []rune(strings.Repeat("·", width))
+	for _, match := range m.Matches {
+		timeline[(match-1)*width/len(m.Steps)] = '|'
+	}
+	timeline[(m.CurrentStep-1)*width/len(m.Steps)] = '▲'
+
+	return status + "  " + string(timeline)
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"main/frame"
+)
+
+func TestGoToStep(t *testing.T) {
+	// Every 25th step was kept, like with -debug-every 25
+	m := Model{Steps: []frame.Frame{{Step: 1}, {Step: 26}, {Step: 51}, {Step: 76}}, CurrentStep: 1}
+
+	tests := []struct {
+		command string
+		want    int
+	}{
+		{command: ":1", want: 1},
+		{command: ":26", want: 2},
+		{command: ":27", want: 3},
+		{command: ":76", want: 4},
+		{command: ":500", want: 4},
+		{command: ":0", want: 1},
+	}
+	for _, test := range tests {
+		t.Run(test.command, func(t *testing.T) {
+			got := m.runCommand(test.command)
+			if got.CurrentStep != test.want {
+				t.Errorf("expected step index %d, got %d", test.want, got.CurrentStep)
+			}
+		})
+	}
+
+	if got, want := m.stepHeader(), "Step(1-76): 1 [1/4]"; got != want {
+		t.Errorf("expected header %q, got %q", want, got)
+	}
+}
+
+func TestCommandBackspace(t *testing.T) {
+	m := Model{Command: "/grün"}
+	m = m.updateCommand(tea.KeyMsg{Type: tea.KeyBackspace})
+	m = m.updateCommand(tea.KeyMsg{Type: tea.KeyBackspace})
+	if m.Command != "/gr" {
+		t.Errorf("expected /gr, got %q", m.Command)
+	}
+}
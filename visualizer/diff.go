@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// changedStyle marks the cells that differ between the pinned and the current step
+var changedStyle = lipgloss.NewStyle().Reverse(true)
+
+// diffView shows the viewport of the pinned step next to the current one with the changed cells
+// marked and a summary of the changes of the whole grid below them
+func (m Model) diffView() string {
+	pinned, current := m.Steps[m.Pinned-1], m.Steps[m.CurrentStep-1]
+	left, right, summary := diffSteps(pinned.Data, current.Data, m.viewport(current))
+
+	columnStyle := lipgloss.NewStyle().PaddingRight(2)
+	columns := lipgloss.JoinHorizontal(lipgloss.Top,
+		columnStyle.Render(lipgloss.JoinVertical(lipgloss.Left, fmt.Sprintf("Step %d (pinned)", m.solverStep(m.Pinned)), left)),
+		columnStyle.Render(lipgloss.JoinVertical(lipgloss.Left, fmt.Sprintf("Step %d", m.solverStep(m.CurrentStep)), right)),
+	)
+	return lipgloss.JoinVertical(lipgloss.Left, columns, "", summary)
+}
+
+// diffSteps compares two steps cell by cell, a cell only one of them has counts as changed. Only
+// the cells in the viewport are drawn, the summary counts every cell.
+func diffSteps(a, b string, viewport image.Rectangle) (string, string, string) {
+	linesA, linesB := strings.Split(a, "\n"), strings.Split(b, "\n")
+	appeared, disappeared := map[rune]int{}, map[rune]int{}
+	changed := 0
+
+	var left, right strings.Builder
+	for y := range max(len(linesA), len(linesB)) {
+		var rowA, rowB []rune
+		if y < len(linesA) {
+			rowA = []rune(linesA[y])
+		}
+		if y < len(linesB) {
+			rowB = []rune(linesB[y])
+		}
+
+		if y > viewport.Min.Y && y < viewport.Max.Y {
+			left.WriteByte('\n')
+			right.WriteByte('\n')
+		}
+		for x := range max(len(rowA), len(rowB)) {
+			var ra, rb rune
+			if x < len(rowA) {
+				ra = rowA[x]
+			}
+			if x < len(rowB) {
+				rb = rowB[x]
+			}
+			visible := image.Pt(x, y).In(viewport)
+
+			if ra == rb {
+				if visible {
+					left.WriteRune(ra)
+					right.WriteRune(rb)
+				}
+				continue
+			}
+
+			changed++
+			if ra != 0 {
+				disappeared[ra]++
+			}
+			if rb != 0 {
+				appeared[rb]++
+			}
+			if !visible {
+				continue
+			}
+
+			if ra != 0 {
+				left.WriteString(changedStyle.Render(string(ra)))
+			} else {
+				left.WriteByte(' ')
+			}
+			if rb != 0 {
+				right.WriteString(changedStyle.Render(string(rb)))
+			} else {
+				right.WriteByte(' ')
+			}
+		}
+	}
+
+	summary := fmt.Sprintf("%d cells changed", changed)
+	if changed > 0 {
+		summary += fmt.Sprintf("\nappeared:    %s\ndisappeared: %s", runeCounts(appeared), runeCounts(disappeared))
+	}
+	return left.String(), right.String(), summary
+}
+
+// runeCounts lists how often every rune occurs, like '#'×3 '.'×1
+func runeCounts(counts map[rune]int) string {
+	if len(counts) == 0 {
+		return "-"
+	}
+
+	runes := make([]rune, 0, len(counts))
+	for r := range counts {
+		runes = append(runes, r)
+	}
+	slices.Sort(runes)
+
+	var parts []string
+	for _, r := range runes {
+		parts = append(parts, fmt.Sprintf("%q×%d", r, counts[r]))
+	}
+	return strings.Join(parts, " ")
+}
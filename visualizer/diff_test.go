@@ -0,0 +1,56 @@
+package main
+
+import (
+	"image"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"main/frame"
+)
+
+func TestDiffSteps(t *testing.T) {
+	a := "#..\n.^.\n..."
+	b := "#..\n...\n.^."
+
+	tests := []struct {
+		name        string
+		viewport    image.Rectangle
+		left, right string
+	}{
+		{name: "whole grid", viewport: image.Rect(0, 0, 3, 3), left: "#..\n.^.\n...", right: "#..\n...\n.^."},
+		{name: "bottom right", viewport: image.Rect(1, 1, 3, 3), left: "^.\n..", right: "..\n^."},
+		{name: "first row", viewport: image.Rect(0, 0, 3, 1), left: "#..", right: "#.."},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			left, right, summary := diffSteps(a, b, test.viewport)
+			if left, right := stripStyles(left), stripStyles(right); left != test.left || right != test.right {
+				t.Errorf("expected %q and %q, got %q and %q", test.left, test.right, left, right)
+			}
+			if !strings.HasPrefix(summary, "2 cells changed") {
+				t.Errorf("expected the changes of the whole grid, got %q", summary)
+			}
+		})
+	}
+}
+
+func TestDiffViewFitsTerminal(t *testing.T) {
+	grid := strings.TrimSuffix(strings.Repeat(strings.Repeat(".", 130)+"\n", 130), "\n")
+	m := Model{Steps: []frame.Frame{{Step: 1, Data: grid}, {Step: 2, Data: "^" + grid[1:]}}, CurrentStep: 2, Pinned: 1, Width: 120, Height: 40}
+
+	view := m.View()
+	if width := lipgloss.Width(view); width > m.Width {
+		t.Errorf("expected the diff to fit into %d columns, got %d", m.Width, width)
+	}
+	if height := lipgloss.Height(view); height > m.Height {
+		t.Errorf("expected the diff to fit into %d rows, got %d", m.Height, height)
+	}
+}
+
+// stripStyles removes the reverse style of the changed cells
+func stripStyles(s string) string {
+	plain, _ := frame.ParseAnsi(s)
+	return plain
+}
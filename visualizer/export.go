@@ -0,0 +1,254 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/alexflint/go-arg"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// exportPadding is the border around the grid in pixels
+const exportPadding = 8
+
+// maxHeat is the heat from which heatColor doesn't change anymore
+const maxHeat = 15
+
+var (
+	exportBackground = color.RGBA{0x1e, 0x1e, 0x1e, 0xff}
+	exportForeground = color.RGBA{0xd0, 0xd0, 0xd0, 0xff}
+)
+
+// export renders steps of a debug file to an animated GIF or PNG, or records the terminal view of
+// them as an asciinema cast or an animated SVG, without a terminal
+func export(arguments []string) error {
+	var args struct {
+		Year   int           `arg:"positional,required"`
+		Day    int           `arg:"positional,required"`
+		Part   int           `arg:"positional" default:"1"`
+		Gif    string        `arg:"--gif" help:"write an animated GIF to this file"`
+		APNG   string        `arg:"--apng" help:"write an animated PNG to this file"`
+		Cast   string        `arg:"--cast" help:"write the terminal view as an asciinema v2 recording to this file"`
+		SVG    string        `arg:"--svg" help:"write the terminal view as an animated SVG to this file"`
+		Colors string        `arg:"-c" default:"heat" help:"color by the heatmap, the colors of the part (solver) or both"`
+		From   int           `arg:"--from" default:"1" help:"first step"`
+		To     int           `arg:"--to" help:"last step, the last step of the file when not set"`
+		Every  int           `arg:"--every" default:"1" help:"only export every Nth step"`
+		Delay  time.Duration `arg:"-d" default:"500ms" help:"how long every step is shown"`
+	}
+	p, err := arg.NewParser(arg.Config{Program: "visualizer export"}, &args)
+	if err != nil {
+		return err
+	}
+	err = p.Parse(arguments)
+	if errors.Is(err, arg.ErrHelp) {
+		p.WriteHelp(os.Stdout)
+		os.Exit(0)
+	}
+	if err != nil {
+		p.Fail(err.Error())
+	}
+	outputs := 0
+	for _, output := range []string{args.Gif, args.APNG, args.Cast, args.SVG} {
+		if output != "" {
+			outputs++
+		}
+	}
+	if outputs != 1 {
+		p.Fail("use one of --gif, --apng, --cast or --svg")
+	}
+	colors := slices.Index(colorModes, args.Colors)
+	if colors < 0 {
+		p.Fail(fmt.Sprintf("unknown colors %#v, use one of %s", args.Colors, strings.Join(colorModes, ", ")))
+	}
+
+	steps, err := readSteps(debugPath(args.Year, args.Day, args.Part))
+	if err != nil {
+		return fmt.Errorf("could not read debug file: %w", err)
+	}
+
+	to := len(steps)
+	if args.To > 0 {
+		to = min(args.To, len(steps))
+	}
+	var stepNumbers []int
+	for step := max(args.From, 1); step <= to; step += max(args.Every, 1) {
+		stepNumbers = append(stepNumbers, step)
+	}
+	if len(stepNumbers) == 0 {
+		return fmt.Errorf("no steps between %d and %d", args.From, to)
+	}
+
+	// The model colors the steps the same way View does
+	m := Model{Steps: steps, StepMod: max(args.Every, 1), Colors: ColorMode(colors)}
+	if args.Cast != "" || args.SVG != "" {
+		return exportViews(m, stepNumbers, args.Cast+args.SVG, args.SVG != "", args.Delay)
+	}
+
+	last := m.solverStep(len(steps))
+	columns, rows := len(fmt.Sprintf("Step %d/%d", last, last)), 0
+	for _, step := range stepNumbers {
+		lines := strings.Split(steps[step-1].Data, "\n")
+		rows = max(rows, len(lines))
+		for _, line := range lines {
+			columns = max(columns, len(line))
+		}
+	}
+
+	images := make([]*image.Paletted, 0, len(stepNumbers))
+	for _, step := range stepNumbers {
+		images = append(images, m.renderStep(step, columns, rows))
+	}
+
+	file, err := os.Create(args.Gif + args.APNG)
+	if err != nil {
+		return fmt.Errorf("could not create export file: %w", err)
+	}
+	defer file.Close()
+
+	if args.Gif != "" {
+		animation := &gif.GIF{Image: images}
+		for range images {
+			animation.Delay = append(animation.Delay, int(args.Delay/(10*time.Millisecond)))
+		}
+		err = gif.EncodeAll(file, animation)
+	} else {
+		err = encodeAPNG(file, images, args.Delay)
+	}
+	if err != nil {
+		return fmt.Errorf("could not encode %s: %w", file.Name(), err)
+	}
+
+	return file.Close()
+}
+
+// exportViews records the view of every step as it autoplays in the terminal
+func exportViews(m Model, stepNumbers []int, path string, svg bool, delay time.Duration) error {
+	// There is no terminal to detect the colors of, record them as a true color terminal shows them
+	lipgloss.SetColorProfile(termenv.TrueColor)
+
+	views := make([]string, 0, len(stepNumbers))
+	for _, step := range stepNumbers {
+		m.CurrentStep = step
+		views = append(views, m.View())
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("could not create export file: %w", err)
+	}
+	defer file.Close()
+
+	if svg {
+		err = encodeSVG(file, views, delay)
+	} else {
+		err = encodeCast(file, views, delay)
+	}
+	if err != nil {
+		return fmt.Errorf("could not encode %s: %w", file.Name(), err)
+	}
+
+	return file.Close()
+}
+
+// renderStep draws the step with a bitmap font on an image fitting columns × rows characters and
+// a line for the step number. The cells are colored like View does for the color mode, hex colors
+// of the part get the closest color of the palette.
+func (m Model) renderStep(step, columns, rows int) *image.Paletted {
+	face := basicfont.Face7x13
+	cellWidth, cellHeight := face.Advance, face.Height
+
+	palette := color.Palette{exportBackground, exportForeground}
+	for heat := 1; heat <= maxHeat; heat++ {
+		palette = append(palette, heatColor(heat))
+	}
+	for _, hex := range ansiPalette {
+		palette = append(palette, hexColor(hex))
+	}
+
+	bounds := image.Rect(0, 0, columns*cellWidth+2*exportPadding, (rows+1)*cellHeight+2*exportPadding)
+	img := image.NewPaletted(bounds, palette)
+	draw.Draw(img, bounds, image.NewUniform(exportBackground), image.Point{}, draw.Src)
+
+	drawText := func(s string, x, y int, c color.Color) {
+		drawer := font.Drawer{
+			Dst:  img,
+			Src:  image.NewUniform(c),
+			Face: face,
+			Dot:  fixed.P(exportPadding+x*cellWidth, exportPadding+y*cellHeight+face.Ascent),
+		}
+		drawer.DrawString(s)
+	}
+
+	drawText(fmt.Sprintf("Step %d/%d", m.solverStep(step), m.solverStep(len(m.Steps))), 0, 0, exportForeground)
+
+	data := m.Steps[step-1].Data
+	solverColors := make([]color.Color, len(data))
+	for _, style := range m.Steps[step-1].Styles {
+		for i := max(style.Offset, 0); i < min(style.Offset+style.Length, len(data)); i++ {
+			solverColors[i] = solverColor(style.Color)
+		}
+	}
+
+	heatmap := m.heatmap(step)
+	x, y := 0, 1
+	for i, b := range []byte(data) {
+		if b == '\n' {
+			x, y = 0, y+1
+			continue
+		}
+
+		var c color.Color = exportForeground
+		heat := heatColor(min(heatmap[i], maxHeat))
+		switch m.Colors {
+		case ColorHeat:
+			if heat != nil {
+				c = heat
+			}
+		case ColorSolver:
+			if solverColors[i] != nil {
+				c = solverColors[i]
+			}
+		case ColorBoth:
+			if solverColors[i] != nil {
+				c = solverColors[i]
+			}
+			if heat != nil {
+				cell := image.Rect(0, 0, cellWidth, cellHeight).Add(image.Pt(exportPadding+x*cellWidth, exportPadding+y*cellHeight))
+				draw.Draw(img, cell, image.NewUniform(heat), image.Point{}, draw.Src)
+			}
+		}
+		drawText(string(b), x, y, c)
+		x++
+	}
+
+	return img
+}
+
+// solverColor is the color of a style of the part, an ANSI color number or a hex color. It is nil
+// for a color it doesn't know.
+func solverColor(s string) color.Color {
+	return hexColor(paletteColor(s, ""))
+}
+
+// hexColor parses a color like #ff0000, it is nil when s isn't one
+func hexColor(s string) color.Color {
+	var r, g, b uint8
+	_, err := fmt.Sscanf(s, "#%02x%02x%02x", &r, &g, &b)
+	if err != nil {
+		return nil
+	}
+	return color.RGBA{R: r, G: g, B: b, A: 0xff}
+}
@@ -1,9 +1,15 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"image"
+	"image/color"
+	"io"
 	"log/slog"
+	"net"
 	"os"
+	"regexp"
 	"slices"
 	"strings"
 	"time"
@@ -12,15 +18,20 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/crazy3lf/colorconv"
-)
 
-const (
-	VisualizeStep = "==========STEP==========\n"
-	VisualizeData = "==========DATA==========\n"
-	VisualizeEnd  = "==========END==========\n"
+	"main/frame"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "export" {
+		err := export(os.Args[2:])
+		if err != nil {
+			slog.Error("could not export", "err", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	var args struct {
 		Year             int           `arg:"positional"`
 		Day              int           `arg:"positional"`
@@ -28,8 +39,15 @@ func main() {
 		AutoPlay         bool          `arg:"-a"`
 		DebugHeat        bool          `arg:"-h"`
 		AutoPlayDuration time.Duration `arg:"-d" default:"500ms"`
+		Attach           bool          `arg:"--attach" help:"stream the steps of a part while it runs with -debug-sink socket"`
+		Colors           string        `arg:"-c" default:"heat" help:"color by the heatmap, the colors of the part (solver) or both"`
+		Follow           string        `arg:"-f" default:"^>v<V" help:"characters the viewport follows with f"`
+	}
+	p := arg.MustParse(&args)
+	colors := slices.Index(colorModes, args.Colors)
+	if colors < 0 {
+		p.Fail(fmt.Sprintf("unknown colors %#v, use one of %s", args.Colors, strings.Join(colorModes, ", ")))
 	}
-	arg.MustParse(&args)
 	// args.Part = 1
 
 	model := Model{
@@ -37,60 +55,174 @@ func main() {
 		StepMod:     1,
 		Paused:      !args.AutoPlay,
 		DebugHeat:   args.DebugHeat,
+		Colors:      ColorMode(colors),
+		Follow:      args.Follow,
 	}
 
-	debugData, err := os.ReadFile(fmt.Sprintf("../%d/day%d/debug-Part%d.txt", args.Year, args.Day, args.Part))
-	if err != nil {
-		slog.Error("could not read debug file", "err", err)
-		os.Exit(1)
-	}
-
-	debug := strings.ReplaceAll(string(debugData), "\r\n", "\n")
-	if !strings.Contains(debug, VisualizeStep) {
-		slog.Error("debug file has no steps")
-		os.Exit(1)
-	}
+	debugPath := debugPath(args.Year, args.Day, args.Part)
 
-	for _, s := range strings.Split(strings.Split(debug, VisualizeEnd)[0], VisualizeStep)[1:] {
-		s := strings.TrimSuffix(s, "\n")
-		data := StepData{Data: s}
-		if strings.Contains(s, VisualizeData) {
-			parts := strings.Split(s, VisualizeData)
-			data.Meta = strings.TrimSuffix(parts[0], "\n")
-			data.Data = strings.TrimSuffix(parts[1], "\n")
+	var conn net.Conn
+	if args.Attach {
+		var err error
+		conn, err = net.Dial("unix", strings.TrimSuffix(debugPath, ".txt")+".sock")
+		if err != nil {
+			slog.Error("could not attach to debug socket", "err", err)
+			os.Exit(1)
 		}
-		model.Steps = append(model.Steps, data)
+		defer conn.Close()
+		model.Live = true
+	} else {
+		steps, err := readSteps(debugPath)
+		if err != nil {
+			slog.Error("could not read debug file", "err", err)
+			os.Exit(1)
+		}
+		model.Steps = steps
 	}
 
-	p := tea.NewProgram(model)
+	program := tea.NewProgram(model)
+
+	if conn != nil {
+		go stream(conn, program)
+	}
 
 	go func() {
 		for {
-			p.Send(new(Tick))
+			program.Send(new(Tick))
 			time.Sleep(args.AutoPlayDuration)
 		}
 	}()
 
-	if _, err := p.Run(); err != nil {
+	if _, err := program.Run(); err != nil {
 		slog.Error("there has been an error", "err", err)
 		os.Exit(1)
 	}
 }
 
+// debugPath is the debug file of a part, relative to the visualizer
+func debugPath(year, day, part int) string {
+	return fmt.Sprintf("../%d/day%d/debug-Part%d.txt", year, day, part)
+}
+
+// readSteps reads every step of a debug file
+func readSteps(path string) ([]frame.Frame, error) {
+	debugData, err := readDebugFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var parser frame.Parser
+	steps := append(parser.Feed(debugData), parser.Close()...)
+	if len(steps) == 0 {
+		return nil, errors.New("debug file has no steps")
+	}
+	return steps, nil
+}
+
+// readDebugFile reads the newest of the debug file and its compressed versions. A compressed file
+// that is still being written is read up to its last complete block.
+func readDebugFile(path string) ([]byte, error) {
+	newestPath := path
+	var newest os.FileInfo
+	for _, ext := range []string{"", frame.CompressExt(frame.CompressGzip), frame.CompressExt(frame.CompressZstd)} {
+		info, err := os.Stat(path + ext)
+		if err == nil && (newest == nil || info.ModTime().After(newest.ModTime())) {
+			newest = info
+			newestPath = path + ext
+		}
+	}
+	path = newestPath
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	r, err := frame.NewReader(file)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, fmt.Errorf("could not decompress %s: %w", path, err)
+	}
+
+	return data, nil
+}
+
 type Tick tea.Msg
 
-type StepData struct {
-	Data string
-	Meta string
+// StepsMsg brings the steps that arrived on the debug socket
+type StepsMsg []frame.Frame
+
+// StreamEndMsg brings the last steps once the part closed the debug socket
+type StreamEndMsg []frame.Frame
+
+// stream reads the steps of a running part and sends them to the program as they complete
+func stream(r io.Reader, p *tea.Program) {
+	var parser frame.Parser
+	buf := make([]byte, 64*1024)
+	for {
+		n, err := r.Read(buf)
+		if steps := parser.Feed(buf[:n]); len(steps) > 0 {
+			p.Send(StepsMsg(steps))
+		}
+
+		if err != nil {
+			p.Send(StreamEndMsg(parser.Close()))
+			return
+		}
+	}
 }
 
+// ColorMode is what colors the cells of a step
+type ColorMode int
+
+const (
+	// ColorHeat colors the cells that changed in the last steps
+	ColorHeat ColorMode = iota
+	// ColorSolver uses the colors the part wrote
+	ColorSolver
+	// ColorBoth uses the colors of the part with the heatmap as background
+	ColorBoth
+)
+
+// colorModes are the names of the color modes for -c
+var colorModes = []string{"heat", "solver", "both"}
+
 type Model struct {
-	Paused      bool
-	DebugHeat   bool
+	// Live is set while steps still arrive from a running part
+	Live      bool
+	Paused    bool
+	DebugHeat bool
+	Colors    ColorMode
+	// Pinned is the step the current one is diffed against, 0 when there is none
+	Pinned int
+	// Layer is the layer of a grid drawn over it, 0 shows every layer next to the grid
+	Layer       int
 	StepMod     int
 	CurrentStep int
 
-	Steps []StepData
+	// Width and Height are the size of the terminal, 0 until it is known
+	Width, Height int
+	// ViewX and ViewY are the top left cell of the viewport into grids bigger than the terminal
+	ViewX, ViewY int
+	// Follow are the characters the viewport is centered on while Following
+	Follow    string
+	Following bool
+	Minimap   bool
+
+	Steps []frame.Frame
+
+	// Command is the command line being typed, starting with : or /, it is closed when empty
+	Command string
+	// Message is the result of the last command
+	Message string
+	Search  *regexp.Regexp
+	// Matches are the steps matching Search in order
+	Matches []int
 }
 
 func (m Model) Init() tea.Cmd {
@@ -100,15 +232,30 @@ func (m Model) Init() tea.Cmd {
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	foreward := func() {
 		maxStep := len(m.Steps)
+		if maxStep == 0 {
+			return
+		}
+
 		m.CurrentStep = min(m.CurrentStep+m.StepMod, maxStep)
-		if m.CurrentStep == maxStep {
+		// A live part keeps playing as new steps arrive
+		if m.CurrentStep == maxStep && !m.Live {
 			m.Paused = true
 		}
 	}
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		if m.Command != "" {
+			return m.updateCommand(msg), nil
+		}
+
 		switch msg.String() {
+		case ":", "/":
+			m.Command = msg.String()
+		case "n":
+			m = m.nextMatch()
+		case "N":
+			m = m.previousMatch()
 		case "ctrl+c", "q":
 			return m, tea.Quit
 		case "left":
@@ -118,7 +265,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "ctrl+left":
 			m.CurrentStep = 1
 		case "ctrl+right":
-			m.CurrentStep = len(m.Steps)
+			m.CurrentStep = max(len(m.Steps), 1)
 		case "alt+1":
 			m.StepMod = 1
 		case "alt+2":
@@ -141,6 +288,37 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.Paused = !m.Paused
 		case "h":
 			m.DebugHeat = !m.DebugHeat
+		case "c":
+			m.Colors = (m.Colors + 1) % ColorMode(len(colorModes))
+		case "p":
+			if m.Pinned != 0 {
+				m.Pinned = 0
+			} else if len(m.Steps) > 0 {
+				m.Pinned = m.CurrentStep
+			}
+		case "shift+left":
+			m = m.scroll(-1, 0)
+		case "shift+down":
+			m = m.scroll(0, 1)
+		case "shift+up":
+			m = m.scroll(0, -1)
+		case "shift+right":
+			m = m.scroll(1, 0)
+		case "pgup":
+			m = m.scroll(0, -m.pageRows())
+		case "pgdown":
+			m = m.scroll(0, m.pageRows())
+		case "home":
+			m.ViewX, m.ViewY = 0, 0
+			m.Following = false
+		case "f":
+			m.Following = !m.Following
+		case "m":
+			m.Minimap = !m.Minimap
+		case "l":
+			if len(m.Steps) > 0 {
+				m.Layer = (m.Layer + 1) % (len(m.Steps[m.CurrentStep-1].Layers) + 1)
+			}
 		case "up":
 			if m.StepMod == 1 {
 				m.StepMod = 5
@@ -161,78 +339,208 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.StepMod = 1
 			}
 		}
+	case tea.WindowSizeMsg:
+		m.Width, m.Height = msg.Width, msg.Height
 	case Tick:
 		if !m.Paused {
 			foreward()
 		}
+	case StepsMsg:
+		m.Steps = append(m.Steps, msg...)
+		m = m.matchSteps(len(m.Steps) - len(msg))
+	case StreamEndMsg:
+		m.Steps = append(m.Steps, msg...)
+		m = m.matchSteps(len(m.Steps) - len(msg))
+		m.Live = false
 	}
 
-	return m, nil
+	return m.updateViewport(), nil
+}
+
+// stepHeader shows the step number of the part and, once steps were dropped, which of the kept
+// steps it is
+func (m Model) stepHeader() string {
+	if len(m.Steps) == 0 {
+		return "Step: -"
+	}
+
+	first, last, current := m.solverStep(1), m.solverStep(len(m.Steps)), m.solverStep(m.CurrentStep)
+	header := fmt.Sprintf("Step(%d-%d): %d", first, last, current)
+	if last-first+1 != len(m.Steps) {
+		header += fmt.Sprintf(" [%d/%d]", m.CurrentStep, len(m.Steps))
+	}
+	return header
 }
 
 func (m Model) View() string {
 	headerStyle := lipgloss.NewStyle().Margin(0, 2)
 	header := lipgloss.JoinHorizontal(lipgloss.Center,
-		headerStyle.Render(fmt.Sprintf("Step(1-%d): %d", len(m.Steps), m.CurrentStep)),
+		headerStyle.Render(m.stepHeader()),
 		headerStyle.Render(fmt.Sprintf("Paused: %t", m.Paused)),
 		headerStyle.Render(fmt.Sprintf("Step Mod: %d", m.StepMod)),
+		headerStyle.Render(fmt.Sprintf("Colors: %s", colorModes[m.Colors])),
 	)
+	if m.Live {
+		header = lipgloss.JoinHorizontal(lipgloss.Center, header, headerStyle.Render("Live"))
+	}
+	if search := m.searchHeader(); search != "" {
+		header = lipgloss.JoinVertical(lipgloss.Left, header, headerStyle.Render(search))
+	}
+
+	// The command line while typing and otherwise the result of the last command
+	var footer []string
+	if m.Command != "" {
+		footer = append(footer, m.Command)
+	} else if m.Message != "" {
+		footer = append(footer, m.Message)
+	}
+
+	if len(m.Steps) == 0 {
+		return box(append([]string{header, "waiting for steps..."}, footer...)...)
+	}
 
 	currentStep := m.Steps[m.CurrentStep-1]
 	current := []byte(currentStep.Data)
-	heatmap := slices.Repeat([]int{0}, len(current))
 
-	maxHeat := 0
-	steps := m.Steps[max(m.CurrentStep-max(20, m.StepMod), 0):min(m.CurrentStep-1, len(m.Steps))]
-	for _, step := range steps {
-		for heatIdx, r := range current {
-			if r != step.Data[heatIdx] {
-				heatmap[heatIdx]++
-				maxHeat = max(heatmap[heatIdx], maxHeat)
-			}
+	columns, rows := gridSize(currentStep.Data)
+	viewport := m.viewport(currentStep)
+	if viewport != image.Rect(0, 0, columns, rows) {
+		view := fmt.Sprintf("View: %d,%d/%dx%d", viewport.Min.X, viewport.Min.Y, columns, rows)
+		if m.Following {
+			view += " follow"
 		}
+		header = lipgloss.JoinHorizontal(lipgloss.Center, header, headerStyle.Render(view))
 	}
 
-	for i, heat := range heatmap {
-		if heat > 0 {
-			heatmap[i] = maxHeat - heat + 1
+	if m.Pinned > 0 && m.Pinned <= len(m.Steps) {
+		header = lipgloss.JoinHorizontal(lipgloss.Center, header, headerStyle.Render(fmt.Sprintf("Diff: %d -> %d", m.solverStep(m.Pinned), m.solverStep(m.CurrentStep))))
+		return box(append([]string{header, m.diffView()}, footer...)...)
+	}
+
+	// Spaces of a layer drawn over the grid are transparent
+	var overlay []byte
+	if m.Layer > 0 && m.Layer <= len(currentStep.Layers) {
+		layer := currentStep.Layers[m.Layer-1]
+		header = lipgloss.JoinHorizontal(lipgloss.Center, header, headerStyle.Render("Layer: "+layer.Name))
+		if len(layer.Data) == len(current) {
+			overlay = []byte(layer.Data)
+		}
+	}
+	heatmap := m.heatmap(m.CurrentStep)
+
+	solverColors := make([]string, len(current))
+	for _, style := range currentStep.Styles {
+		for i := max(style.Offset, 0); i < min(style.Offset+style.Length, len(current)); i++ {
+			solverColors[i] = style.Color
 		}
 	}
 
 	result := ""
 	heatDebug := ""
+	x, y := 0, 0
 	for i, b := range current {
+		if overlay != nil && overlay[i] != ' ' {
+			b = overlay[i]
+		}
 		if b == '\n' {
-			heatDebug += "\n"
-			result += "\n"
+			// Only lines of the viewport are kept
+			if y >= viewport.Min.Y && y < viewport.Max.Y-1 {
+				heatDebug += "\n"
+				result += "\n"
+			}
+			x, y = 0, y+1
+			continue
+		}
+		if !image.Pt(x, y).In(viewport) {
+			x++
 			continue
 		}
+		x++
 
 		hex := ""
-		heat := heatmap[i]
-		if heat > 0 {
-			color, err := colorconv.HSVToColor(float64(min(280, (heat-1)*20)), 1, 1)
-			if err != nil {
-				slog.Error("could not convert heatmap to color", "heat", heat, "err", err)
-			}
-			hex = strings.Replace(colorconv.ColorToHex(color), "0x", "#", 1)
+		if c := heatColor(heatmap[i]); c != nil {
+			hex = strings.Replace(colorconv.ColorToHex(c), "0x", "#", 1)
 		}
 
-		result += lipgloss.NewStyle().
-			Foreground(lipgloss.Color(hex)).
-			Render(string(b))
+		style := lipgloss.NewStyle()
+		switch m.Colors {
+		case ColorHeat:
+			style = style.Foreground(lipgloss.Color(hex))
+		case ColorSolver:
+			style = style.Foreground(lipgloss.Color(solverColors[i]))
+		case ColorBoth:
+			style = style.Foreground(lipgloss.Color(solverColors[i])).Background(lipgloss.Color(hex))
+		}
+		result += style.Render(string(b))
 		heatDebug += fmt.Sprintf(" %02d", heatmap[i])
 	}
 
+	if m.Layer == 0 && len(currentStep.Layers) > 0 {
+		panes := []string{lipgloss.JoinVertical(lipgloss.Left, "", result)}
+		for _, layer := range currentStep.Layers {
+			panes = append(panes, lipgloss.NewStyle().PaddingLeft(2).Render(lipgloss.JoinVertical(lipgloss.Left, layer.Name, cropLines(layer.Data, viewport))))
+		}
+		result = lipgloss.JoinHorizontal(lipgloss.Top, panes...)
+	}
+
+	side := stepMeta(currentStep)
+	if m.Minimap {
+		side = strings.TrimSuffix(minimap(currentStep, viewport)+"\n\n"+side, "\n\n")
+	}
+
 	if m.DebugHeat {
 		result = lipgloss.JoinHorizontal(lipgloss.Center, result, heatDebug)
-	} else if currentStep.Meta != "" {
-		result = lipgloss.JoinHorizontal(lipgloss.Top, result, lipgloss.NewStyle().Padding(0, 1).Render(currentStep.Meta))
+	} else if side != "" {
+		result = lipgloss.JoinHorizontal(lipgloss.Top, result, lipgloss.NewStyle().Padding(0, 1).Render(side))
 	}
 
+	return box(append([]string{header, result}, footer...)...)
+}
+
+// heatmap is how recently every byte of the step changed compared to the 20 steps before it, or
+// the steps skipped by StepMod when that is more. 1 is the most recent change, 0 is no change.
+func (m Model) heatmap(step int) []int {
+	current := m.Steps[step-1].Data
+	heatmap := slices.Repeat([]int{0}, len(current))
+
+	maxHeat := 0
+	steps := m.Steps[max(step-max(20, m.StepMod), 0):min(step-1, len(m.Steps))]
+	for _, step := range steps {
+		for heatIdx := range min(len(current), len(step.Data)) {
+			if current[heatIdx] != step.Data[heatIdx] {
+				heatmap[heatIdx]++
+				maxHeat = max(heatmap[heatIdx], maxHeat)
+			}
+		}
+	}
+
+	for i, heat := range heatmap {
+		if heat > 0 {
+			heatmap[i] = maxHeat - heat + 1
+		}
+	}
+	return heatmap
+}
+
+// heatColor is the color of a heat from the heatmap, nil for a byte that didn't change
+func heatColor(heat int) color.Color {
+	if heat <= 0 {
+		return nil
+	}
+
+	// HSVToColor leaves the alpha at 0, which would be transparent in an exported image
+	r, g, b, err := colorconv.HSVToRGB(float64(min(280, (heat-1)*20)), 1, 1)
+	if err != nil {
+		slog.Error("could not convert heatmap to color", "heat", heat, "err", err)
+	}
+	return color.RGBA{R: r, G: g, B: b, A: 0xff}
+}
+
+// box draws the border around the rows of the view
+func box(rows ...string) string {
 	return lipgloss.NewStyle().
 		Border(lipgloss.DoubleBorder()).
 		Padding(0, 1).
 		MarginBottom(1).
-		Render(lipgloss.JoinVertical(lipgloss.Left, header, result))
+		Render(lipgloss.JoinVertical(lipgloss.Left, rows...))
 }
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"main/frame"
+)
+
+// minimapWidth and minimapHeight are how many characters the minimap takes at most
+const (
+	minimapWidth  = 32
+	minimapHeight = 16
+)
+
+// minimapShades shade a block of the minimap by how many of its cells aren't empty
+var minimapShades = []rune(" ░▒▓█")
+
+// viewportStyle marks the blocks of the minimap that are in the viewport
+var viewportStyle = lipgloss.NewStyle().Background(lipgloss.Color("8"))
+
+// gridSize is the number of columns and rows of the data of a step
+func gridSize(data string) (int, int) {
+	lines := strings.Split(data, "\n")
+	columns := 0
+	for _, line := range lines {
+		columns = max(columns, len(line))
+	}
+	return columns, len(lines)
+}
+
+// viewport is the part of the grid of the step that fits into the terminal next to the layers and
+// the side pane, starting at ViewX, ViewY. Without the size of the terminal it is the whole grid.
+func (m Model) viewport(step frame.Frame) image.Rectangle {
+	columns, rows := gridSize(step.Data)
+	if m.Width == 0 || m.Height == 0 {
+		return image.Rect(0, 0, columns, rows)
+	}
+
+	// The box takes 4 columns and 3 rows and the header a row
+	width := m.Width - 4 - m.sideWidth(step)
+	height := m.Height - 4
+	if m.Search != nil {
+		height--
+	}
+	if m.Command != "" || m.Message != "" {
+		height--
+	}
+
+	panes := 1
+	if m.Pinned > 0 {
+		// The diff shows both steps with 2 columns of padding and a title above them and a summary
+		// of up to 3 rows below them, without the layers or the side pane
+		panes = 2
+		width = m.Width - 4 - 2*2
+		height -= 5
+	} else {
+		if m.Layer == 0 && len(step.Layers) > 0 {
+			// Every layer is as wide as the grid with 2 columns of padding and its name above it
+			panes += len(step.Layers)
+			width -= 2 * len(step.Layers)
+			height--
+		}
+		if m.DebugHeat {
+			// Every heat takes 3 columns
+			panes += 3
+		}
+	}
+	width, height = max(width/panes, 1), max(height, 1)
+
+	x := min(max(m.ViewX, 0), max(columns-width, 0))
+	y := min(max(m.ViewY, 0), max(rows-height, 0))
+	return image.Rect(x, y, min(x+width, columns), min(y+height, rows))
+}
+
+// sideWidth is how many columns the meta and minimap next to the grid take
+func (m Model) sideWidth(step frame.Frame) int {
+	if m.DebugHeat {
+		return 0
+	}
+
+	width := 0
+	if meta := stepMeta(step); meta != "" {
+		width = lipgloss.Width(meta) + 2
+	}
+	if m.Minimap {
+		columns, rows := gridSize(step.Data)
+		scale := minimapScale(columns, rows)
+		width = max(width, (columns+scale-1)/scale+2)
+	}
+	return width
+}
+
+// updateViewport centers the viewport on the first followed character of the current step while
+// following and keeps it inside the grid, so scrolling back from an edge moves it right away
+func (m Model) updateViewport() Model {
+	if len(m.Steps) == 0 || m.CurrentStep < 1 || m.CurrentStep > len(m.Steps) {
+		return m
+	}
+	step := m.Steps[m.CurrentStep-1]
+
+	if m.Following {
+		if i := strings.IndexAny(step.Data, m.Follow); i >= 0 && m.Follow != "" {
+			viewport := m.viewport(step)
+			x := i - strings.LastIndexByte(step.Data[:i], '\n') - 1
+			y := strings.Count(step.Data[:i], "\n")
+			m.ViewX, m.ViewY = x-viewport.Dx()/2, y-viewport.Dy()/2
+		}
+	}
+
+	viewport := m.viewport(step)
+	m.ViewX, m.ViewY = viewport.Min.X, viewport.Min.Y
+	return m
+}
+
+// scroll moves the viewport by columns and rows and stops following
+func (m Model) scroll(columns, rows int) Model {
+	m.ViewX += columns
+	m.ViewY += rows
+	m.Following = false
+	return m
+}
+
+// pageRows is how many rows the page keys scroll
+func (m Model) pageRows() int {
+	if len(m.Steps) == 0 {
+		return 1
+	}
+	return max(m.viewport(m.Steps[m.CurrentStep-1]).Dy()-1, 1)
+}
+
+// cropLines cuts the viewport out of the lines of a layer
+func cropLines(data string, viewport image.Rectangle) string {
+	lines := strings.Split(data, "\n")
+	lines = lines[min(viewport.Min.Y, len(lines)):min(viewport.Max.Y, len(lines))]
+	for i, line := range lines {
+		lines[i] = line[min(viewport.Min.X, len(line)):min(viewport.Max.X, len(line))]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// minimapScale is how many columns and rows of the grid a block of the minimap covers
+func minimapScale(columns, rows int) int {
+	return max((columns+minimapWidth-1)/minimapWidth, (rows+minimapHeight-1)/minimapHeight, 1)
+}
+
+// minimap shrinks the grid of the step into blocks shaded by how many of their cells aren't empty
+// ('.' and ' ' are), the blocks in the viewport are marked
+func minimap(step frame.Frame, viewport image.Rectangle) string {
+	lines := strings.Split(step.Data, "\n")
+	columns, rows := gridSize(step.Data)
+	scale := minimapScale(columns, rows)
+	cropped := viewport != image.Rect(0, 0, columns, rows)
+
+	var result strings.Builder
+	for y := 0; y < rows; y += scale {
+		if y > 0 {
+			result.WriteByte('\n')
+		}
+		for x := 0; x < columns; x += scale {
+			filled, cells := 0, 0
+			for _, line := range lines[y:min(y+scale, rows)] {
+				for _, b := range []byte(line[min(x, len(line)):min(x+scale, len(line))]) {
+					cells++
+					if b != '.' && b != ' ' {
+						filled++
+					}
+				}
+			}
+
+			shade := minimapShades[0]
+			if filled > 0 {
+				shade = minimapShades[1+filled*(len(minimapShades)-2)/cells]
+			}
+			if cropped && image.Rect(x, y, x+scale, y+scale).Overlaps(viewport) {
+				result.WriteString(viewportStyle.Render(string(shade)))
+				continue
+			}
+			result.WriteRune(shade)
+		}
+	}
+	return result.String()
+}
+
+// stepMeta is the meta of a step followed by its annotations
+func stepMeta(step frame.Frame) string {
+	meta := step.Meta
+	for _, annotation := range step.Annotations {
+		meta += fmt.Sprintf("\n%d,%d: %s", annotation.X, annotation.Y, annotation.Label)
+	}
+	return strings.TrimPrefix(meta, "\n")
+}